@@ -0,0 +1,98 @@
+package universe
+
+import (
+	"github.com/relvacode/universe/internal"
+)
+
+//go:generate go run github.com/abice/go-enum -f=$GOFILE --marshal
+
+// BodyKind is how an entity is moved by the simulation.
+//
+// BodyKindDynamic entities are moved by gravity and collisions.
+//
+// BodyKindStatic entities never move. They attract other entities but behave as if they had infinite mass in collisions.
+//
+// BodyKindKinematic entities move at a constant velocity, or along their Path if they have one, and ignore every force.
+// Like static entities they attract other entities and behave as if they had infinite mass in collisions.
+/*
+ENUM(
+dynamic,
+static,
+kinematic
+)
+*/
+type BodyKind uint8
+
+// KinematicPath is the scripted position of a kinematic entity at a time in seconds since the simulation started.
+type KinematicPath interface {
+	Position(t float64) internal.Vector
+}
+
+// KinematicPathFunc is a user-supplied KinematicPath.
+type KinematicPathFunc func(t float64) internal.Vector
+
+func (f KinematicPathFunc) Position(t float64) internal.Vector {
+	return f(t)
+}
+
+// Dynamic checks if the entity is moved by gravity and collisions.
+func (e *Entity) Dynamic() bool {
+	return e.Kind == BodyKindDynamic
+}
+
+// inverseMass returns the inverse of the mass of an entity in a collision,
+// which is zero for entities that cannot be moved by collisions.
+func (e *Entity) inverseMass() float64 {
+	if !e.Dynamic() {
+		return 0
+	}
+	return 1 / e.M
+}
+
+// prepareBodies sets the velocity of every entity that is not dynamic at the start of a step.
+// Static entities are stopped and kinematic entities with a path are given the velocity
+// that moves them to their position on the path at the end of the step.
+// Because the acceleration of these entities is always zero, every integrator moves them in a straight line.
+func (s *Simulation) prepareBodies(timestep float64) {
+	for _, e := range s.entities {
+		switch e.Kind {
+		case BodyKindStatic:
+			e.V = internal.Vector{}
+			e.A = internal.Vector{}
+		case BodyKindKinematic:
+			e.A = internal.Vector{}
+			if e.Path == nil {
+				continue
+			}
+
+			// The step has already been counted so the current time is the end of the step
+			p := e.Path.Position(s.Time())
+			e.V = internal.Vector{
+				X: (p.X - e.P.X) / timestep,
+				Y: (p.Y - e.P.Y) / timestep,
+			}
+		}
+	}
+}
+
+// SetKindByID changes the body kind of the entity with the given ID.
+// Returns false if there is no entity with the ID.
+func (s *Simulation) SetKindByID(id EntityID, kind BodyKind) bool {
+	e, ok := s.index[id]
+	if !ok {
+		return false
+	}
+
+	s.syncEntities()
+
+	update := snapshotEntity(e)
+	update.Kind = kind
+	s.record(ReplayEvent{
+		Kind:   ReplayEventKindUpdate,
+		Entity: &update,
+	})
+
+	e.Kind = kind
+	s.loadParticle(e)
+	return true
+}
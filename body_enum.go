@@ -0,0 +1,63 @@
+// Code generated by go-enum
+// DO NOT EDIT!
+
+package universe
+
+import (
+	"fmt"
+)
+
+const (
+	// BodyKindDynamic is a BodyKind of type Dynamic
+	BodyKindDynamic BodyKind = iota
+	// BodyKindStatic is a BodyKind of type Static
+	BodyKindStatic
+	// BodyKindKinematic is a BodyKind of type Kinematic
+	BodyKindKinematic
+)
+
+const _BodyKindName = "dynamicstatickinematic"
+
+var _BodyKindMap = map[BodyKind]string{
+	0: _BodyKindName[0:7],
+	1: _BodyKindName[7:13],
+	2: _BodyKindName[13:22],
+}
+
+// String implements the Stringer interface.
+func (x BodyKind) String() string {
+	if str, ok := _BodyKindMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("BodyKind(%d)", x)
+}
+
+var _BodyKindValue = map[string]BodyKind{
+	_BodyKindName[0:7]:   0,
+	_BodyKindName[7:13]:  1,
+	_BodyKindName[13:22]: 2,
+}
+
+// ParseBodyKind attempts to convert a string to a BodyKind
+func ParseBodyKind(name string) (BodyKind, error) {
+	if x, ok := _BodyKindValue[name]; ok {
+		return x, nil
+	}
+	return BodyKind(0), fmt.Errorf("%s is not a valid BodyKind", name)
+}
+
+// MarshalText implements the text marshaller method
+func (x BodyKind) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method
+func (x *BodyKind) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseBodyKind(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
@@ -0,0 +1,104 @@
+package universe
+
+import (
+	"github.com/relvacode/universe/internal"
+	"math"
+)
+
+//go:generate go run github.com/abice/go-enum -f=$GOFILE --marshal
+
+// BoundaryMode is how entities behave at the edges of the world boundary.
+//
+// BoundaryModeOpen lets entities leave the world boundary.
+//
+// BoundaryModeReflect bounces entities off the edges of the world boundary.
+//
+// BoundaryModeWrap moves entities that leave one edge of the world boundary to the opposite edge.
+// Gravity is calculated from the periodic images of every entity in the eight neighbouring copies of the world,
+// which is nine times slower than the other modes.
+//
+// BoundaryModeAbsorb removes entities once their centre leaves the world boundary.
+/*
+ENUM(
+open,
+reflect,
+wrap,
+absorb
+)
+*/
+type BoundaryMode uint8
+
+func (s *Simulation) BoundaryMode() BoundaryMode {
+	return s.boundaryMode
+}
+
+// SetBoundaryMode sets how entities behave at the edges of the world boundary.
+func (s *Simulation) SetBoundaryMode(mode BoundaryMode) {
+	s.boundaryMode = mode
+	s.recordPhysics()
+}
+
+// applyBoundary applies the boundary mode to every entity after they have moved.
+// An event is emitted for each entity whose centre has left the world boundary since the last step.
+func (s *Simulation) applyBoundary() {
+	box := s.worldBoundary
+	if box.W <= 0 || box.H <= 0 {
+		return
+	}
+
+	for _, e := range s.entities {
+		if e.Disabled {
+			continue
+		}
+
+		outside := !box.ContainsPoint(e.P)
+		if outside && !e.outside && s.emitting() {
+			s.emit(BoundaryExitEvent{Step: s.steps, Entity: e, Mode: s.boundaryMode})
+		}
+
+		switch s.boundaryMode {
+		case BoundaryModeReflect:
+			e.ReflectBounds(box)
+		case BoundaryModeWrap:
+			e.P.X = box.X + wrap(e.P.X-box.X, box.W)
+			e.P.Y = box.Y + wrap(e.P.Y-box.Y, box.H)
+		case BoundaryModeAbsorb:
+			if outside {
+				e.Disabled = true
+				if s.emitting() {
+					s.emit(RemovedEvent{Step: s.steps, Entity: e, Cause: EventCauseBoundary})
+				}
+			}
+		}
+
+		e.outside = !box.ContainsPoint(e.P)
+	}
+}
+
+// wrap returns x modulo size in the range [0, size).
+func wrap(x, size float64) float64 {
+	x = math.Mod(x, size)
+	if x < 0 {
+		x += size
+	}
+	if x >= size {
+		x = 0
+	}
+	return x
+}
+
+// imageOffsets returns the offset of each periodic image of the world that attracts entities.
+// Only the world itself attracts entities unless the simulation wraps around its boundary.
+func (s *Simulation) imageOffsets() []internal.Vector {
+	if s.boundaryMode != BoundaryModeWrap || s.worldBoundary.W <= 0 || s.worldBoundary.H <= 0 {
+		return []internal.Vector{{}}
+	}
+
+	offsets := make([]internal.Vector, 0, 9)
+	for _, y := range [3]float64{0, -s.worldBoundary.H, s.worldBoundary.H} {
+		for _, x := range [3]float64{0, -s.worldBoundary.W, s.worldBoundary.W} {
+			offsets = append(offsets, internal.Vector{X: x, Y: y})
+		}
+	}
+	return offsets
+}
@@ -0,0 +1,67 @@
+// Code generated by go-enum
+// DO NOT EDIT!
+
+package universe
+
+import (
+	"fmt"
+)
+
+const (
+	// BoundaryModeOpen is a BoundaryMode of type Open
+	BoundaryModeOpen BoundaryMode = iota
+	// BoundaryModeReflect is a BoundaryMode of type Reflect
+	BoundaryModeReflect
+	// BoundaryModeWrap is a BoundaryMode of type Wrap
+	BoundaryModeWrap
+	// BoundaryModeAbsorb is a BoundaryMode of type Absorb
+	BoundaryModeAbsorb
+)
+
+const _BoundaryModeName = "openreflectwrapabsorb"
+
+var _BoundaryModeMap = map[BoundaryMode]string{
+	0: _BoundaryModeName[0:4],
+	1: _BoundaryModeName[4:11],
+	2: _BoundaryModeName[11:15],
+	3: _BoundaryModeName[15:21],
+}
+
+// String implements the Stringer interface.
+func (x BoundaryMode) String() string {
+	if str, ok := _BoundaryModeMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("BoundaryMode(%d)", x)
+}
+
+var _BoundaryModeValue = map[string]BoundaryMode{
+	_BoundaryModeName[0:4]:   0,
+	_BoundaryModeName[4:11]:  1,
+	_BoundaryModeName[11:15]: 2,
+	_BoundaryModeName[15:21]: 3,
+}
+
+// ParseBoundaryMode attempts to convert a string to a BoundaryMode
+func ParseBoundaryMode(name string) (BoundaryMode, error) {
+	if x, ok := _BoundaryModeValue[name]; ok {
+		return x, nil
+	}
+	return BoundaryMode(0), fmt.Errorf("%s is not a valid BoundaryMode", name)
+}
+
+// MarshalText implements the text marshaller method
+func (x BoundaryMode) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method
+func (x *BoundaryMode) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseBoundaryMode(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
@@ -0,0 +1,237 @@
+package universe
+
+import (
+	"github.com/relvacode/universe/internal"
+	"github.com/relvacode/universe/physics"
+	"math"
+	"sort"
+	"time"
+)
+
+// contactCollisionResolver is implemented by collision resolvers that must be given entities at the moment they first touch.
+// Other resolvers are given the entities at their closest approach within the step,
+// so that resolvers which depend on how much entities overlap see the deepest overlap.
+type contactCollisionResolver interface {
+	resolvesOnContact()
+}
+
+func (MassReflectCollisionResolver) resolvesOnContact() {}
+
+func (RestitutionCollisionResolver) resolvesOnContact() {}
+
+// sweepEvent is a collision between entities i and j at time t within a step.
+type sweepEvent struct {
+	t    float64
+	i, j int
+}
+
+func (s *Simulation) ContinuousCollisions() bool {
+	return s.continuousCollisions
+}
+
+// SetContinuousCollisions enables or disables continuous collision detection.
+// Entities normally only collide if they overlap at the end of a step,
+// so small and fast moving entities can pass through each other within a single step.
+// Continuous collision detection sweeps each entity along its path through the step
+// and resolves collisions in the order they happen.
+func (s *Simulation) SetContinuousCollisions(enabled bool) {
+	s.continuousCollisions = enabled
+	s.recordPhysics()
+}
+
+// beginSweep records the position of each entity at the start of a step.
+func (s *Simulation) beginSweep() {
+	if !s.continuousCollisions {
+		return
+	}
+
+	s.sweepPosition = s.sweepPosition[:0]
+	for _, e := range s.entities {
+		s.sweepPosition = append(s.sweepPosition, e.P)
+	}
+}
+
+// sweep finds every pair of entities whose paths since beginSweep touch and resolves their collisions in order.
+// Each entity is assumed to have moved in a straight line over the step.
+// Entities involved in a collision continue in a straight line at their resolved velocity for the rest of the step.
+func (s *Simulation) sweep(timestep float64) {
+	if !s.continuousCollisions || timestep <= 0 || len(s.sweepPosition) != len(s.entities) {
+		return
+	}
+
+	perf := time.Now()
+	defer func() {
+		s.stats.Collisions += time.Now().Sub(perf)
+	}()
+
+	n := len(s.entities)
+	if cap(s.sweepVelocity) < n {
+		s.sweepVelocity = make([]internal.Vector, n)
+		s.sweepTime = make([]float64, n)
+		s.sweepMoved = make([]bool, n)
+		s.sweepBounds = make([]internal.BoundingBox, n)
+	}
+	s.sweepVelocity = s.sweepVelocity[:n]
+	s.sweepTime = s.sweepTime[:n]
+	s.sweepMoved = s.sweepMoved[:n]
+	s.sweepBounds = s.sweepBounds[:n]
+
+	s.sweepOrder = s.sweepOrder[:0]
+	for i, e := range s.entities {
+		start := s.sweepPosition[i]
+		s.sweepVelocity[i] = internal.Vector{
+			X: (e.P.X - start.X) / timestep,
+			Y: (e.P.Y - start.Y) / timestep,
+		}
+		s.sweepTime[i] = 0
+		s.sweepMoved[i] = false
+		s.sweepBounds[i] = internal.BoundingBox{
+			X: math.Min(start.X, e.P.X) - e.R,
+			Y: math.Min(start.Y, e.P.Y) - e.R,
+			W: math.Abs(e.P.X-start.X) + 2*e.R,
+			H: math.Abs(e.P.Y-start.Y) + 2*e.R,
+		}
+
+		if !e.Disabled {
+			s.sweepOrder = append(s.sweepOrder, i)
+		}
+	}
+
+	// Sort and sweep the bounds of each path along the x axis to find candidate pairs
+	sort.Slice(s.sweepOrder, func(a, b int) bool {
+		i, j := s.sweepOrder[a], s.sweepOrder[b]
+		if s.sweepBounds[i].X != s.sweepBounds[j].X {
+			return s.sweepBounds[i].X < s.sweepBounds[j].X
+		}
+		return i < j
+	})
+
+	_, onContact := s.collisionResolver.(contactCollisionResolver)
+
+	s.sweepEvents = s.sweepEvents[:0]
+	for a, i := range s.sweepOrder {
+		bi := s.sweepBounds[i]
+		for _, j := range s.sweepOrder[a+1:] {
+			bj := s.sweepBounds[j]
+			if bj.X > bi.X+bi.W {
+				break
+			}
+			if bj.Y > bi.Y+bi.H || bi.Y > bj.Y+bj.H {
+				continue
+			}
+
+			t, ok := sweepContact(s.sweepObject(i, 0), s.sweepObject(j, 0), onContact)
+			if !ok || t > timestep {
+				continue
+			}
+
+			event := sweepEvent{t: t, i: i, j: j}
+			if j < i {
+				event.i, event.j = j, i
+			}
+			s.sweepEvents = append(s.sweepEvents, event)
+		}
+	}
+
+	if len(s.sweepEvents) == 0 {
+		return
+	}
+
+	sort.Slice(s.sweepEvents, func(a, b int) bool {
+		ea, eb := s.sweepEvents[a], s.sweepEvents[b]
+		if ea.t != eb.t {
+			return ea.t < eb.t
+		}
+		if ea.i != eb.i {
+			return ea.i < eb.i
+		}
+		return ea.j < eb.j
+	})
+
+	var created EntityList
+	for _, event := range s.sweepEvents {
+		e1, e2 := s.entities[event.i], s.entities[event.j]
+		if e1.Disabled || e2.Disabled {
+			continue
+		}
+
+		// The paths of either entity may have changed since the event was found
+		t0 := math.Max(s.sweepTime[event.i], s.sweepTime[event.j])
+		o1, o2 := s.sweepObject(event.i, t0), s.sweepObject(event.j, t0)
+
+		contact, ok := sweepContact(o1, o2, onContact)
+		if !ok || t0+contact > timestep {
+			continue
+		}
+
+		t := t0 + contact
+		if !onContact {
+			t = math.Min(math.Max(t0+physics.ClosestApproachTime(o1, o2), t), timestep)
+		}
+
+		s.sweepMove(event.i, t)
+		s.sweepMove(event.j, t)
+
+		distance, _ := physics.Colliding(e1.Object, e2.Object)
+		created = append(created, s.resolve(s.collisionResolver, e1, e2, distance)...)
+
+		// Continue along the resolved paths from the position of the resolved entities
+		for _, i := range [2]int{event.i, event.j} {
+			s.sweepPosition[i] = s.entities[i].P
+			s.sweepVelocity[i] = s.entities[i].V
+		}
+	}
+
+	for i, e := range s.entities {
+		if e.Disabled || !s.sweepMoved[i] {
+			continue
+		}
+
+		s.sweepMove(i, timestep)
+	}
+
+	s.appendCreated(created)
+}
+
+// sweepContact calculates the time at which two entities moving along their paths first touch.
+// Entities that already overlap touch immediately, unless the resolver resolves on contact,
+// in which case they are left to discrete collision detection.
+func sweepContact(o1, o2 physics.Object, onContact bool) (float64, bool) {
+	t := physics.CollisionTime(o1, o2)
+	if math.IsInf(t, 1) {
+		return 0, false
+	}
+
+	if t < 0 {
+		return 0, !onContact
+	}
+
+	return t, true
+}
+
+// sweepObject returns an entity at time t along its path.
+func (s *Simulation) sweepObject(i int, t float64) physics.Object {
+	e := s.entities[i]
+	p := s.sweepPosition[i]
+	v := s.sweepVelocity[i]
+	dt := t - s.sweepTime[i]
+
+	return physics.Object{
+		P: internal.Vector{
+			X: p.X + v.X*dt,
+			Y: p.Y + v.Y*dt,
+		},
+		V: v,
+		R: e.R,
+		M: e.M,
+	}
+}
+
+// sweepMove moves an entity to its position at time t along its path.
+func (s *Simulation) sweepMove(i int, t float64) {
+	o := s.sweepObject(i, t)
+	s.entities[i].P = o.P
+	s.sweepPosition[i] = o.P
+	s.sweepTime[i] = t
+	s.sweepMoved[i] = true
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/relvacode/universe"
+)
+
+var eventsHeader = []string{
+	"step", "event", "detail", "id", "other_id", "x", "y", "m", "other_m", "speed",
+}
+
+// eventRecord describes a simulation event as a CSV record.
+// Collisions are placed at the point of contact on the surface of the first entity.
+func eventRecord(event universe.Event) []string {
+	f := func(v float64) string {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	id := func(e *universe.Entity) string {
+		return strconv.FormatUint(uint64(e.ID), 10)
+	}
+
+	switch event := event.(type) {
+	case universe.CollisionEvent:
+		p := event.E1.P
+		if event.Distance > 0 {
+			p.X += (event.E2.P.X - event.E1.P.X) / event.Distance * event.E1.R
+			p.Y += (event.E2.P.Y - event.E1.P.Y) / event.Distance * event.E1.R
+		}
+		return []string{
+			strconv.Itoa(event.Step), "collision", "", id(event.E1), id(event.E2), f(p.X), f(p.Y),
+			f(event.E1.M), f(event.E2.M), f(math.Sqrt(event.RelativeVelocity.Dot())),
+		}
+	case universe.MergeEvent:
+		return []string{
+			strconv.Itoa(event.Step), "merge", "", id(event.Into), id(event.Merged), f(event.Into.P.X), f(event.Into.P.Y),
+			f(event.Into.M), f(event.Merged.M), f(math.Sqrt(event.Into.V.Dot())),
+		}
+	case universe.CreatedEvent:
+		return []string{
+			strconv.Itoa(event.Step), "created", event.Cause.String(), id(event.Entity), "", f(event.Entity.P.X), f(event.Entity.P.Y),
+			f(event.Entity.M), "", f(math.Sqrt(event.Entity.V.Dot())),
+		}
+	case universe.RemovedEvent:
+		return []string{
+			strconv.Itoa(event.Step), "removed", event.Cause.String(), id(event.Entity), "", f(event.Entity.P.X), f(event.Entity.P.Y),
+			f(event.Entity.M), "", f(math.Sqrt(event.Entity.V.Dot())),
+		}
+	case universe.BoundaryExitEvent:
+		return []string{
+			strconv.Itoa(event.Step), "boundary_exit", event.Mode.String(), id(event.Entity), "", f(event.Entity.P.X), f(event.Entity.P.Y),
+			f(event.Entity.M), "", f(math.Sqrt(event.Entity.V.Dot())),
+		}
+	}
+
+	return nil
+}
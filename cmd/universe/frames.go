@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	stddraw "image/draw"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/relvacode/universe"
+	"github.com/relvacode/universe/draw"
+)
+
+// frameWriter renders frames of a simulation and writes them as PNG or SVG images or an animated GIF.
+type frameWriter struct {
+	raster     *draw.Raster
+	camera     draw.Camera
+	background string
+	foreground string
+
+	png bool
+	svg bool
+	gif *gif.GIF
+	// delay is the delay between GIF frames in 100ths of a second
+	delay int
+}
+
+func newFrameWriter(w, h int, camera draw.Camera, background, foreground string) *frameWriter {
+	fw := &frameWriter{
+		raster:     draw.NewRaster(w, h),
+		camera:     camera,
+		background: background,
+		foreground: foreground,
+	}
+
+	fw.style(fw.raster)
+	return fw
+}
+
+func (fw *frameWriter) style(ctx draw.Canvas) {
+	ctx.Push(draw.FillStyle, fw.foreground)
+	ctx.Push(draw.StrokeStyle, fw.foreground)
+	ctx.Push(draw.LineWidth, 1)
+	ctx.Push(draw.TextBaseline, "top")
+}
+
+func (fw *frameWriter) render(ctx draw.Canvas, s *universe.Simulation, w, h float64) {
+	ctx.ClearRect(0, 0, w, h)
+
+	ctx.Push(draw.FillStyle, fw.background)
+	ctx.BeginPath()
+	ctx.Rect(0, 0, w, h)
+	ctx.Fill()
+	ctx.Pop(draw.FillStyle)
+
+	s.Draw(ctx, fw.camera)
+
+	ctx.FillText(fmt.Sprintf("step %d, %.2fs, %d entities", s.Steps(), s.Time(), s.Len()), 10, 10)
+}
+
+func (fw *frameWriter) writeSVG(s *universe.Simulation, w, h float64) error {
+	svg := draw.NewSVG(w, h)
+	fw.style(svg)
+	fw.render(svg, s, w, h)
+
+	f, err := os.Create(filepath.Join(*flagOut, fmt.Sprintf("frame-%08d.svg", s.Steps())))
+	if err != nil {
+		return err
+	}
+
+	if _, err := svg.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// write renders the current state of the simulation as a new frame.
+func (fw *frameWriter) write(s *universe.Simulation) error {
+	bounds := fw.raster.Image().Bounds()
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+
+	if fw.svg {
+		if err := fw.writeSVG(s, w, h); err != nil {
+			return err
+		}
+	}
+
+	// Skip rendering the raster if only SVG frames are written
+	if !fw.png && fw.gif == nil {
+		return nil
+	}
+
+	fw.render(fw.raster, s, w, h)
+	if err := fw.raster.Err(); err != nil {
+		return err
+	}
+
+	img := fw.raster.Image()
+
+	if fw.gif != nil {
+		paletted := image.NewPaletted(img.Bounds(), palette.Plan9)
+		stddraw.Draw(paletted, img.Bounds(), img, image.Point{}, stddraw.Src)
+
+		fw.gif.Image = append(fw.gif.Image, paletted)
+		fw.gif.Delay = append(fw.gif.Delay, fw.delay)
+	}
+
+	if !fw.png {
+		return nil
+	}
+
+	f, err := os.Create(filepath.Join(*flagOut, fmt.Sprintf("frame-%08d.png", s.Steps())))
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// close writes the animated GIF of every frame.
+func (fw *frameWriter) close(name string) error {
+	if fw.gif == nil || len(fw.gif.Image) == 0 {
+		return nil
+	}
+
+	f, err := os.Create(filepath.Join(*flagOut, name))
+	if err != nil {
+		return err
+	}
+
+	if err := gif.EncodeAll(f, fw.gif); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/relvacode/universe"
+	"github.com/relvacode/universe/draw"
+	"github.com/relvacode/universe/internal"
+	"github.com/relvacode/universe/physics"
+	"github.com/relvacode/universe/scenarios"
+)
+
+var (
+	flagSnapshot = flag.String("snapshot", "", "Snapshot to load the initial state from, a random field is generated if empty")
+	flagScenario = flag.String("scenario", "", "Bundled scenario name or JSON or YAML scenario file to set up the initial state from, used if -snapshot is empty")
+	flagEntities = flag.Int("n", 1024, "Number of entities in a generated random field")
+	flagSeed     = flag.Int64("seed", universe.DefaultSeed, "Random seed of the simulation")
+	flagWidth    = flag.Float64("width", 1920, "Width of the world boundary")
+	flagHeight   = flag.Float64("height", 1080, "Height of the world boundary")
+
+	flagSteps   = flag.Int("steps", 0, "Number of fixed timesteps to run")
+	flagSeconds = flag.Float64("seconds", 0, "Number of simulated seconds to run, used if -steps is 0")
+
+	flagIntegrator   = flag.String("integrator", "", "Integrator, one of euler, leapfrog, verlet or rk4")
+	flagResolver     = flag.String("resolver", "", "Collision resolver, one of noop, absorb, mass-reflect, restitution or fragmentation")
+	flagRestitution  = flag.Float64("restitution", universe.DefaultRestitution, "Coefficient of restitution of the restitution collision resolver")
+	flagCorrection   = flag.Float64("correction", universe.DefaultCollisionCorrection, "Fraction of overlap corrected by the restitution collision resolver")
+	flagThreshold    = flag.Float64("fragmentation-threshold", universe.DefaultFragmentationThreshold, "Impact energy per unit mass required to fragment entities")
+	flagFragments    = flag.Int("fragments", universe.DefaultFragments, "Number of fragments created by a fragmenting collision")
+	flagFragmentMass = flag.Float64("min-fragment-mass", universe.DefaultMinimumFragmentMass, "Minimum mass of a fragment")
+	flagMergePos     = flag.String("merge-position", "barycentre", "Position of merged entities, one of barycentre or consumer")
+	flagMergeRadius  = flag.String("merge-radius", "mass", "Radius of merged entities, one of mass, volume or density")
+	flagMergeMeta    = flag.String("merge-metadata", "keep", "Metadata of merged entities, one of keep, union or discard")
+	flagForceLaw     = flag.String("force-law", "", "Force law, one of inverse-square, inverse-linear, plummer or softened-inverse-linear")
+	flagG            = flag.Float64("g", physics.G, "Gravitational constant")
+	flagSoftening    = flag.Float64("softening", 0, "Gravitational softening length")
+	flagTheta        = flag.Float64("theta", universe.DefaultTheta, "Barnes-Hut opening angle")
+	flagTimestepMode = flag.String("timestep-mode", "", "Timestep mode, one of FixedTimestep, AdaptiveTimestep or BlockTimestep")
+	flagCCD          = flag.Bool("ccd", false, "Enable continuous collision detection")
+	flagBoundary     = flag.String("boundary", "open", "Boundary mode, one of open, reflect, wrap or absorb")
+	flagWorkers      = flag.Int("workers", 0, "Number of workers used to calculate gravity, 0 uses every CPU")
+	flagParticles    = flag.Bool("particles", false, "Calculate gravity from a structure-of-arrays copy of the entities")
+
+	flagOut        = flag.String("out", "out", "Directory to write snapshots and statistics to")
+	flagFormat     = flag.String("format", "json", "Snapshot format, one of json or binary")
+	flagEvery      = flag.Int("every", 0, "Write a snapshot every N steps, 0 only writes the final snapshot")
+	flagStatsEvery = flag.Int("stats-every", 10, "Write statistics every N steps, 0 disables statistics")
+	flagEvents     = flag.Bool("events", false, "Write every collision, merge, created, removed and boundary exit event")
+
+	flagFrameEvery = flag.Int("frame-every", 0, "Render a frame every N steps, 0 disables rendering")
+	flagPNG        = flag.Bool("png", true, "Write each rendered frame as a PNG image")
+	flagSVG        = flag.Bool("svg", false, "Write each rendered frame as an SVG image")
+	flagGIF        = flag.String("gif", "", "Write rendered frames as an animated GIF with this name")
+	flagGIFDelay   = flag.Int("gif-delay", 4, "Delay between GIF frames in 100ths of a second")
+	flagZoom       = flag.Float64("zoom", 1, "Camera zoom of rendered frames, centred on the world boundary")
+	flagBackground = flag.String("background", "#000000", "Background colour of rendered frames")
+	flagColor      = flag.String("color", "#00b3ff", "Colour of entities in rendered frames")
+)
+
+// explicitFlags returns the names of the flags that were set on the command line.
+func explicitFlags() map[string]bool {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+func loadSnapshot(s *universe.Simulation, path string) (*universe.Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	snapshot, err := universe.Load(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, s.Restore(snapshot)
+}
+
+// loadScenario sets up the simulation from a bundled scenario with the given name or from a scenario file.
+// The seed given on the command line overrides the seed of the scenario.
+func loadScenario(s *universe.Simulation, name string) (*universe.Scenario, error) {
+	scenario, err := scenarios.Load(name)
+	if err != nil {
+		f, ferr := os.Open(name)
+		if ferr != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		scenario, err = universe.LoadScenario(f)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if explicitFlags()["seed"] {
+		scenario.Seed = *flagSeed
+	}
+
+	return scenario, s.Setup(scenario)
+}
+
+func generateRandomField(s *universe.Simulation, n int) {
+	box := s.WorldBoundary()
+	rng := s.Rand()
+
+	for i := 0; i < n; i++ {
+		s.Add(universe.NewEntity(
+			internal.Vector{
+				X: box.X + box.W*rng.Float64(),
+				Y: box.Y + box.H*rng.Float64(),
+			},
+			internal.Vector{},
+			2,
+		))
+	}
+}
+
+// configureMerge returns a function that applies the merge parameters given on the command line to a resolver.
+func configureMerge(set map[string]bool) (func(universe.AbsorbCollisionResolver) universe.AbsorbCollisionResolver, error) {
+	position, err := universe.ParseMergePosition(*flagMergePos)
+	if err != nil {
+		return nil, err
+	}
+
+	radius, err := universe.ParseMergeRadius(*flagMergeRadius)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := universe.ParseMetadataMerge(*flagMergeMeta)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(r universe.AbsorbCollisionResolver) universe.AbsorbCollisionResolver {
+		if set["merge-position"] {
+			r.Position = position
+		}
+		if set["merge-radius"] {
+			r.Radius = radius
+		}
+		if set["merge-metadata"] {
+			r.Metadata = metadata
+		}
+		return r
+	}, nil
+}
+
+// configure applies the physics parameters given on the command line,
+// overriding those loaded from a snapshot.
+func configure(s *universe.Simulation) error {
+	set := explicitFlags()
+
+	if set["integrator"] {
+		integrator, ok := universe.IntegratorByName(*flagIntegrator)
+		if !ok {
+			return fmt.Errorf("unknown integrator %q", *flagIntegrator)
+		}
+		s.SetIntegrator(integrator)
+	}
+
+	if set["resolver"] {
+		resolver, ok := universe.CollisionResolverByName(*flagResolver)
+		if !ok {
+			return fmt.Errorf("unknown collision resolver %q", *flagResolver)
+		}
+		s.SetCollisionResolver(resolver)
+	}
+
+	if r, ok := s.CollisionResolver().(universe.RestitutionCollisionResolver); ok {
+		if set["restitution"] {
+			r.Restitution = *flagRestitution
+		}
+		if set["correction"] {
+			r.Correction = *flagCorrection
+		}
+		s.SetCollisionResolver(r)
+	}
+
+	merge, err := configureMerge(set)
+	if err != nil {
+		return err
+	}
+
+	if r, ok := s.CollisionResolver().(universe.AbsorbCollisionResolver); ok {
+		s.SetCollisionResolver(merge(r))
+	}
+
+	if r, ok := s.CollisionResolver().(universe.FragmentationCollisionResolver); ok {
+		r.Merge = merge(r.Merge)
+		if set["fragmentation-threshold"] {
+			r.Threshold = *flagThreshold
+		}
+		if set["fragments"] {
+			r.Fragments = *flagFragments
+		}
+		if set["min-fragment-mass"] {
+			r.MinimumMass = *flagFragmentMass
+		}
+		s.SetCollisionResolver(r)
+	}
+
+	if set["timestep-mode"] {
+		mode, err := universe.ParseTimestepMode(*flagTimestepMode)
+		if err != nil {
+			return err
+		}
+		s.SetTimestepMode(mode)
+	}
+
+	if set["theta"] {
+		s.SetTheta(*flagTheta)
+	}
+
+	if set["ccd"] {
+		s.SetContinuousCollisions(*flagCCD)
+	}
+
+	if set["boundary"] {
+		mode, err := universe.ParseBoundaryMode(*flagBoundary)
+		if err != nil {
+			return err
+		}
+		s.SetBoundaryMode(mode)
+	}
+
+	g := s.Gravity()
+	if set["force-law"] {
+		law, ok := physics.ForceLawByName(*flagForceLaw)
+		if !ok {
+			return fmt.Errorf("unknown force law %q", *flagForceLaw)
+		}
+		g.Law = law
+	}
+	if set["g"] {
+		g.G = *flagG
+	}
+	if set["softening"] {
+		g.Softening = *flagSoftening
+	}
+	s.SetGravity(g)
+
+	s.SetWorkers(*flagWorkers)
+	s.SetParticleStorage(*flagParticles)
+
+	return nil
+}
+
+func writeSnapshot(s *universe.Simulation, format universe.SnapshotFormat) error {
+	snapshot, err := s.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	ext := "json"
+	if format == universe.SnapshotFormatBinary {
+		ext = "bin"
+	}
+
+	f, err := os.Create(filepath.Join(*flagOut, fmt.Sprintf("snapshot-%08d.%s", s.Steps(), ext)))
+	if err != nil {
+		return err
+	}
+
+	if err := universe.Save(f, snapshot, format); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+var statsHeader = []string{
+	"step", "time", "entities", "mass",
+	"kinetic_energy", "potential_energy", "energy", "energy_drift",
+	"momentum_x", "momentum_y", "momentum_drift",
+	"angular_momentum", "angular_momentum_drift",
+	"substeps", "collisions", "interactions_ns", "collisions_ns", "step_ns",
+}
+
+func statsRecord(s *universe.Simulation, baseline universe.Diagnostics, elapsed time.Duration) []string {
+	d := s.ComputeDiagnostics()
+	stats := s.Stats()
+	energyDrift, momentumDrift, angularMomentumDrift := d.Drift(baseline)
+
+	f := func(v float64) string {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	return []string{
+		strconv.Itoa(s.Steps()), f(s.Time()), strconv.Itoa(d.Entities), f(d.Mass),
+		f(d.KineticEnergy), f(d.PotentialEnergy), f(d.Energy()), f(energyDrift),
+		f(d.Momentum.X), f(d.Momentum.Y), f(momentumDrift),
+		f(d.AngularMomentum), f(angularMomentumDrift),
+		strconv.Itoa(stats.Substeps), strconv.Itoa(stats.ResolvedCollisions), strconv.FormatInt(int64(stats.Interactions), 10), strconv.FormatInt(int64(stats.Collisions), 10), strconv.FormatInt(int64(elapsed), 10),
+	}
+}
+
+// run runs the simulation described by the command line.
+// Errors are returned rather than exiting so that deferred writers are flushed and closed.
+func run() error {
+	format, err := universe.ParseSnapshotFormat(*flagFormat)
+	if err != nil {
+		return err
+	}
+
+	steps := *flagSteps
+	if steps == 0 {
+		steps = int(*flagSeconds / universe.PhysicsConstantTimestep)
+	}
+	if steps <= 0 {
+		return fmt.Errorf("either -steps or -seconds must be given")
+	}
+
+	s := universe.NewSimulation(internal.BoundingBox{W: *flagWidth, H: *flagHeight})
+	s.SetSeed(*flagSeed)
+
+	camera := draw.Camera{Zoom: 1}
+
+	if *flagSnapshot != "" {
+		snapshot, err := loadSnapshot(s, *flagSnapshot)
+		if err != nil {
+			return err
+		}
+		if snapshot.Camera.Zoom > 0 {
+			camera = snapshot.Camera
+		}
+	} else if *flagScenario != "" {
+		scenario, err := loadScenario(s, *flagScenario)
+		if err != nil {
+			return err
+		}
+		camera = scenario.Camera.Camera(s.WorldBoundary())
+	} else {
+		generateRandomField(s, *flagEntities)
+	}
+
+	if explicitFlags()["zoom"] {
+		camera.Zoom = *flagZoom
+		camera.FitCenter(s.WorldBoundary())
+	}
+
+	if err := configure(s); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(*flagOut, 0755); err != nil {
+		return err
+	}
+
+	var stats *csv.Writer
+	if *flagStatsEvery > 0 {
+		f, err := os.Create(filepath.Join(*flagOut, "stats.csv"))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		stats = csv.NewWriter(f)
+		defer stats.Flush()
+
+		if err := stats.Write(statsHeader); err != nil {
+			return err
+		}
+	}
+
+	var events *csv.Writer
+	if *flagEvents {
+		f, err := os.Create(filepath.Join(*flagOut, "events.csv"))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		events = csv.NewWriter(f)
+		defer events.Flush()
+
+		if err := events.Write(eventsHeader); err != nil {
+			return err
+		}
+
+		s.Subscribe(func(event universe.Event) {
+			// Write errors are kept by the writer and reported once the simulation has finished
+			_ = events.Write(eventRecord(event))
+		})
+	}
+
+	var frames *frameWriter
+	if *flagFrameEvery > 0 {
+		frames = newFrameWriter(int(*flagWidth), int(*flagHeight), camera, *flagBackground, *flagColor)
+		frames.png = *flagPNG
+		frames.svg = *flagSVG
+		frames.delay = *flagGIFDelay
+		if *flagGIF != "" {
+			frames.gif = new(gif.GIF)
+		}
+
+		if err := frames.write(s); err != nil {
+			return err
+		}
+	}
+
+	baseline := s.ComputeDiagnostics()
+	fmt.Printf("running %d steps of %d entities\n", steps, s.Len())
+
+	perf := time.Now()
+	for i := 1; i <= steps; i++ {
+		stepPerf := time.Now()
+		s.Step(1)
+		elapsed := time.Now().Sub(stepPerf)
+
+		if stats != nil && i%*flagStatsEvery == 0 {
+			if err := stats.Write(statsRecord(s, baseline, elapsed)); err != nil {
+				return err
+			}
+		}
+
+		if *flagEvery > 0 && i%*flagEvery == 0 {
+			if err := writeSnapshot(s, format); err != nil {
+				return err
+			}
+		}
+
+		if frames != nil && i%*flagFrameEvery == 0 {
+			if err := frames.write(s); err != nil {
+				return err
+			}
+		}
+	}
+
+	if frames != nil {
+		if err := frames.close(*flagGIF); err != nil {
+			return err
+		}
+	}
+
+	if *flagEvery <= 0 || steps%*flagEvery != 0 {
+		if err := writeSnapshot(s, format); err != nil {
+			return err
+		}
+	}
+
+	if stats != nil {
+		stats.Flush()
+		if err := stats.Error(); err != nil {
+			return err
+		}
+	}
+
+	if events != nil {
+		events.Flush()
+		if err := events.Error(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("finished %d steps in %s, %d entities remaining\n", steps, time.Now().Sub(perf), s.Len())
+	return nil
+}
+
+// universe runs a simulation without a browser, writing periodic snapshots, statistics and rendered frames to disk.
+func main() {
+	flag.Parse()
+
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
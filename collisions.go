@@ -6,14 +6,95 @@ import (
 	"math"
 )
 
-type CollisionResolver func(e1, e2 *Entity, dist float64)
+//go:generate go run github.com/abice/go-enum -f=$GOFILE --marshal
 
-func MassReflectCollisionResolver(e1, e2 *Entity, _ float64) {
+// CollisionResolver resolves a collision between two overlapping entities.
+// Entities removed by the resolver are marked as disabled.
+// Entities created by the resolver, such as debris, are returned and added to the simulation.
+type CollisionResolver interface {
+	Resolve(e1, e2 *Entity, distance float64) []*Entity
+}
+
+// CollisionResolverFunc is a user-supplied collision resolver.
+type CollisionResolverFunc func(e1, e2 *Entity, distance float64) []*Entity
+
+func (f CollisionResolverFunc) Resolve(e1, e2 *Entity, distance float64) []*Entity {
+	return f(e1, e2, distance)
+}
+
+const (
+	// DefaultRestitution is the default coefficient of restitution of RestitutionCollisionResolver.
+	DefaultRestitution = 0.5
+	// DefaultCollisionCorrection is the default fraction of the overlap between two entities removed by RestitutionCollisionResolver.
+	DefaultCollisionCorrection = 0.8
+)
+
+// CollisionResolverByName returns the built-in collision resolver with the given name.
+// Parameterised resolvers are returned with their default parameters.
+func CollisionResolverByName(name string) (CollisionResolver, bool) {
+	switch name {
+	case "noop":
+		return NoopCollisionResolver{}, true
+	case "absorb":
+		return AbsorbCollisionResolver{}, true
+	case "mass-reflect":
+		return MassReflectCollisionResolver{}, true
+	case "restitution":
+		return RestitutionCollisionResolver{
+			Restitution: DefaultRestitution,
+			Correction:  DefaultCollisionCorrection,
+		}, true
+	case "fragmentation":
+		return FragmentationCollisionResolver{
+			Threshold:   DefaultFragmentationThreshold,
+			Fragments:   DefaultFragments,
+			MinimumMass: DefaultMinimumFragmentMass,
+		}, true
+	}
+
+	return nil, false
+}
+
+// CollisionResolverName returns the name of a built-in collision resolver.
+func CollisionResolverName(resolver CollisionResolver) (string, bool) {
+	switch resolver.(type) {
+	case NoopCollisionResolver:
+		return "noop", true
+	case AbsorbCollisionResolver:
+		return "absorb", true
+	case MassReflectCollisionResolver:
+		return "mass-reflect", true
+	case RestitutionCollisionResolver:
+		return "restitution", true
+	case FragmentationCollisionResolver:
+		return "fragmentation", true
+	}
+
+	return "", false
+}
+
+// MassReflectCollisionResolver reflects the velocities of both entities in a perfectly elastic collision.
+// Entities that are not dynamic are immovable and only the velocity of the other entity is reflected.
+type MassReflectCollisionResolver struct{}
+
+// reflect reflects the velocities of two entities in a perfectly elastic collision.
+func (MassReflectCollisionResolver) reflect(e1, e2 *Entity) {
+	switch {
+	case e1.Dynamic() && e2.Dynamic():
+		physics.Reflect(&e1.Object, &e2.Object)
+	case e1.Dynamic():
+		physics.ReflectImmovable(&e1.Object, e2.Object)
+	case e2.Dynamic():
+		physics.ReflectImmovable(&e2.Object, e1.Object)
+	}
+}
+
+func (r MassReflectCollisionResolver) Resolve(e1, e2 *Entity, _ float64) []*Entity {
 	timeColliding := physics.CollisionTime(e1.Object, e2.Object) * .5
-	if timeColliding == 0 {
+	if timeColliding == 0 || math.IsInf(timeColliding, 0) {
 		// No adjustments need to be made other than reflect the velocities
-		physics.Reflect(&e1.Object, &e2.Object)
-		return
+		r.reflect(e1, e2)
+		return nil
 	}
 
 	// Undo half the time each object was colliding
@@ -21,14 +102,77 @@ func MassReflectCollisionResolver(e1, e2 *Entity, _ float64) {
 	e2.Step(timeColliding)
 
 	// Reflect the velocities
-	physics.Reflect(&e1.Object, &e2.Object)
+	r.reflect(e1, e2)
 
 	// Step each object in the other direction for the other half of the time
 	e1.Step(-timeColliding)
-	e1.Step(-timeColliding)
+	e2.Step(-timeColliding)
+	return nil
+}
+
+// RestitutionCollisionResolver resolves collisions with an impulse along the collision normal.
+//
+// Restitution is the ratio of the speed at which the entities separate to the speed at which they approached,
+// 1 is a perfectly elastic collision and 0 is perfectly inelastic.
+//
+// Correction is the fraction of the overlap between the entities removed by moving them apart,
+// in proportion to their inverse mass, so that resting entities do not sink into each other.
+//
+// Entities that are not dynamic have an infinite mass and are neither moved nor change velocity.
+type RestitutionCollisionResolver struct {
+	Restitution float64 `json:"restitution"`
+	Correction  float64 `json:"correction"`
+}
+
+// collisionSlop is the overlap allowed between two entities before their positions are corrected.
+const collisionSlop = 0.01
+
+func (r RestitutionCollisionResolver) Resolve(e1, e2 *Entity, distance float64) []*Entity {
+	normal := internal.Vector{X: 1}
+	if distance > 0 {
+		normal = internal.Vector{
+			X: (e2.P.X - e1.P.X) / distance,
+			Y: (e2.P.Y - e1.P.Y) / distance,
+		}
+	}
+
+	im1, im2 := e1.inverseMass(), e2.inverseMass()
+	inverseMass := im1 + im2
+	if inverseMass == 0 {
+		return nil
+	}
+
+	// Only apply an impulse when the entities are moving towards each other
+	approach := (e2.V.X-e1.V.X)*normal.X + (e2.V.Y-e1.V.Y)*normal.Y
+	if approach < 0 {
+		j := -(1 + r.Restitution) * approach / inverseMass
+
+		e1.V.X -= j * im1 * normal.X
+		e1.V.Y -= j * im1 * normal.Y
+		e2.V.X += j * im2 * normal.X
+		e2.V.Y += j * im2 * normal.Y
+	}
+
+	overlap := e1.R + e2.R - distance
+	if overlap <= collisionSlop || r.Correction <= 0 {
+		return nil
+	}
+
+	correction := r.Correction * (overlap - collisionSlop) / inverseMass
+
+	e1.P.X -= correction * im1 * normal.X
+	e1.P.Y -= correction * im1 * normal.Y
+	e2.P.X += correction * im2 * normal.X
+	e2.P.Y += correction * im2 * normal.Y
+	return nil
 }
 
-func NoopCollisionResolver(_, _ *Entity, _ float64) {}
+// NoopCollisionResolver ignores collisions.
+type NoopCollisionResolver struct{}
+
+func (NoopCollisionResolver) Resolve(_, _ *Entity, _ float64) []*Entity {
+	return nil
+}
 
 func areaOverlap(r1, r2, distance float64) float64 {
 	var rr1 = r1 * r1
@@ -41,10 +185,53 @@ func areaOverlap(r1, r2, distance float64) float64 {
 	return area1 + area2
 }
 
-func AbsorbCollisionResolver(e1, e2 *Entity, distance float64) {
+// MergePosition is where the entity resulting from a merge is placed.
+/*
+ENUM(
+barycentre,
+consumer
+)
+*/
+type MergePosition uint8
+
+// MergeRadius is how the radius of the entity resulting from a merge is calculated.
+//
+// MergeRadiusMass uses the cube root of the combined mass, as for entities created by NewEntity.
+//
+// MergeRadiusVolume conserves the combined volume of both entities.
+//
+// MergeRadiusDensity keeps the density of the larger entity.
+/*
+ENUM(
+mass,
+volume,
+density
+)
+*/
+type MergeRadius uint8
+
+// AbsorbCollisionResolver merges the smaller entity into the larger once most of it overlaps the larger entity.
+// The combined entity conserves mass and linear momentum exactly.
+// By default it is placed at the centre of mass of both entities,
+// MergePositionConsumer keeps it at the position of the larger entity instead.
+// The metadata of both entities is combined by Metadata.
+//
+// An entity that is not dynamic always absorbs a dynamic entity without moving or changing velocity,
+// entities that are both not dynamic are never merged.
+type AbsorbCollisionResolver struct {
+	Position MergePosition `json:"position"`
+	Radius   MergeRadius   `json:"radius"`
+	Metadata MetadataMerge `json:"metadata"`
+}
+
+func (r AbsorbCollisionResolver) Resolve(e1, e2 *Entity, distance float64) []*Entity {
+	if !e1.Dynamic() && !e2.Dynamic() {
+		return nil
+	}
+
 	var consumer = e1
 	var consumed = e2
-	if e2.M > e1.M {
+	if !e2.Dynamic() || e1.Dynamic() && e2.M > e1.M {
 		consumer, consumed = consumed, consumer
 	}
 
@@ -52,20 +239,34 @@ func AbsorbCollisionResolver(e1, e2 *Entity, distance float64) {
 	overlapArea := areaOverlap(consumer.R, consumed.R, distance)
 
 	if overlapArea/consumedArea < .8 {
-		return
+		return nil
 	}
 
 	consumed.Disabled = true
 
-	massVelocity := internal.Vector{
-		X: (consumer.M * consumer.V.X) + (consumed.M * consumed.V.X),
-		Y: (consumer.M * consumer.V.Y) + (consumed.M * consumed.V.Y),
+	mass := consumer.M + consumed.M
+
+	if consumer.Dynamic() {
+		if r.Position == MergePositionBarycentre {
+			consumer.P.X = (consumer.M*consumer.P.X + consumed.M*consumed.P.X) / mass
+			consumer.P.Y = (consumer.M*consumer.P.Y + consumed.M*consumed.P.Y) / mass
+		}
+
+		consumer.V.X = (consumer.M*consumer.V.X + consumed.M*consumed.V.X) / mass
+		consumer.V.Y = (consumer.M*consumer.V.Y + consumed.M*consumed.V.Y) / mass
 	}
 
-	consumer.V.X = massVelocity.X / consumer.M
-	consumer.V.Y = massVelocity.Y / consumer.M
+	switch r.Radius {
+	case MergeRadiusVolume:
+		consumer.R = math.Cbrt(math.Pow(consumer.R, 3) + math.Pow(consumed.R, 3))
+	case MergeRadiusDensity:
+		consumer.R *= math.Cbrt(mass / consumer.M)
+	default:
+		consumer.R = math.Cbrt(mass)
+	}
 
-	consumer.M = consumer.M + consumed.M
-	consumer.R = math.Cbrt(consumer.M)
+	consumer.M = mass
+	consumer.Metadata = r.Metadata.Merge(consumer.Metadata, consumed.Metadata)
 
+	return nil
 }
@@ -0,0 +1,114 @@
+// Code generated by go-enum
+// DO NOT EDIT!
+
+package universe
+
+import (
+	"fmt"
+)
+
+const (
+	// MergePositionBarycentre is a MergePosition of type Barycentre
+	MergePositionBarycentre MergePosition = iota
+	// MergePositionConsumer is a MergePosition of type Consumer
+	MergePositionConsumer
+)
+
+const _MergePositionName = "barycentreconsumer"
+
+var _MergePositionMap = map[MergePosition]string{
+	0: _MergePositionName[0:10],
+	1: _MergePositionName[10:18],
+}
+
+// String implements the Stringer interface.
+func (x MergePosition) String() string {
+	if str, ok := _MergePositionMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("MergePosition(%d)", x)
+}
+
+var _MergePositionValue = map[string]MergePosition{
+	_MergePositionName[0:10]:  0,
+	_MergePositionName[10:18]: 1,
+}
+
+// ParseMergePosition attempts to convert a string to a MergePosition
+func ParseMergePosition(name string) (MergePosition, error) {
+	if x, ok := _MergePositionValue[name]; ok {
+		return x, nil
+	}
+	return MergePosition(0), fmt.Errorf("%s is not a valid MergePosition", name)
+}
+
+// MarshalText implements the text marshaller method
+func (x MergePosition) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method
+func (x *MergePosition) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseMergePosition(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+const (
+	// MergeRadiusMass is a MergeRadius of type Mass
+	MergeRadiusMass MergeRadius = iota
+	// MergeRadiusVolume is a MergeRadius of type Volume
+	MergeRadiusVolume
+	// MergeRadiusDensity is a MergeRadius of type Density
+	MergeRadiusDensity
+)
+
+const _MergeRadiusName = "massvolumedensity"
+
+var _MergeRadiusMap = map[MergeRadius]string{
+	0: _MergeRadiusName[0:4],
+	1: _MergeRadiusName[4:10],
+	2: _MergeRadiusName[10:17],
+}
+
+// String implements the Stringer interface.
+func (x MergeRadius) String() string {
+	if str, ok := _MergeRadiusMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("MergeRadius(%d)", x)
+}
+
+var _MergeRadiusValue = map[string]MergeRadius{
+	_MergeRadiusName[0:4]:   0,
+	_MergeRadiusName[4:10]:  1,
+	_MergeRadiusName[10:17]: 2,
+}
+
+// ParseMergeRadius attempts to convert a string to a MergeRadius
+func ParseMergeRadius(name string) (MergeRadius, error) {
+	if x, ok := _MergeRadiusValue[name]; ok {
+		return x, nil
+	}
+	return MergeRadius(0), fmt.Errorf("%s is not a valid MergeRadius", name)
+}
+
+// MarshalText implements the text marshaller method
+func (x MergeRadius) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method
+func (x *MergeRadius) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseMergeRadius(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
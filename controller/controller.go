@@ -1,3 +1,5 @@
+// +build js
+
 package controller
 
 import (
@@ -12,15 +14,22 @@ import (
 var _ universe.View = (*View)(nil)
 
 func New(initial internal.BoundingBox, collisionResolver universe.CollisionResolver) *View {
-	var el universe.EntityList
+	simulation := universe.NewSimulation(initial)
+	simulation.SetCollisionResolver(collisionResolver)
+	simulation.SetTrackDiagnostics(true)
+
 	return &View{
-		box:               initial,
-		entities:          &el,
-		collisionResolver: collisionResolver,
-		timescale:         1,
+		box:        initial,
+		simulation: simulation,
+		entities:   simulation.Entities(),
+		timescale:  1,
+		scenario:   -1,
 		inputs: NewInputController(initial,
 			new(PlayStateInput),
 			new(DeleteStateInput),
+			new(BoundaryModeInput),
+			new(PinStateInput),
+			new(ScenarioInput),
 		),
 		camera: &draw.Camera{
 			Zoom: 1,
@@ -29,9 +38,9 @@ func New(initial internal.BoundingBox, collisionResolver universe.CollisionResol
 }
 
 type View struct {
-	box               internal.BoundingBox
-	entities          *universe.EntityList
-	collisionResolver universe.CollisionResolver
+	box        internal.BoundingBox
+	simulation *universe.Simulation
+	entities   *universe.EntityList
 
 	timescale float64
 	paused    bool
@@ -41,6 +50,11 @@ type View struct {
 	inputs       *InputController
 	targetCursor string
 	mouseHandler MouseHandler
+
+	// selected is the ID of the most recently clicked entity
+	selected universe.EntityID
+	// scenario is the index of the most recently loaded bundled scenario
+	scenario int
 }
 
 func (v *View) TimeScale() float64 {
@@ -50,18 +64,14 @@ func (v *View) TimeScale() float64 {
 	return v.timescale
 }
 
-func (v *View) CollisionResolver() universe.CollisionResolver {
-	return v.collisionResolver
+func (v *View) Simulation() *universe.Simulation {
+	return v.simulation
 }
 
 func (v *View) Camera() *draw.Camera {
 	return v.camera
 }
 
-func (v *View) Entities() *universe.EntityList {
-	return v.entities
-}
-
 func (v *View) Update(world internal.BoundingBox) {
 	v.box = world
 	v.inputs.Update(world)
@@ -80,8 +90,8 @@ func (v *View) SetCursor(cursor string) {
 	js.Global().Get("document").Get("body").Get("style").Set("cursor", cursor)
 }
 
-func (v *View) Draw(ctx *draw.Context) {
-	ctx.Clear(v.box)
+func (v *View) Draw(ctx draw.Canvas) {
+	ctx.ClearRect(v.box.X, v.box.Y, v.box.W, v.box.H)
 
 	if v.paused && len(*v.entities) < 256 {
 		v.drawForwardProjections(ctx)
@@ -91,11 +101,68 @@ func (v *View) Draw(ctx *draw.Context) {
 		v.mouseHandler.Draw(v, ctx)
 	}
 
+	v.drawSelection(ctx)
+
 	ctx.Push(draw.FillStyle, "#FFFFFF")
 	v.inputs.Draw(v, ctx)
 	ctx.Pop(draw.FillStyle)
 }
 
+// selectedEntity returns the selected entity if it is still in the simulation.
+func (v *View) selectedEntity() *universe.Entity {
+	e, ok := v.simulation.EntityByID(v.selected)
+	if !ok || e.Disabled {
+		return nil
+	}
+	return e
+}
+
+// togglePin switches the selected entity between a dynamic and a static body.
+func (v *View) togglePin() {
+	e := v.selectedEntity()
+	if e == nil {
+		return
+	}
+
+	kind := universe.BodyKindStatic
+	if e.Kind == universe.BodyKindStatic {
+		kind = universe.BodyKindDynamic
+	}
+
+	v.simulation.SetKindByID(e.ID, kind)
+}
+
+// drawSelection draws a ring around the selected entity.
+func (v *View) drawSelection(ctx draw.Canvas) {
+	e := v.selectedEntity()
+	if e == nil {
+		return
+	}
+
+	color := colorDefault
+	if !e.Dynamic() {
+		color = colorPinned
+	}
+
+	ctx.Push(draw.StrokeStyle, color)
+	ctx.BeginPath()
+	ctx.Arc((e.P.X-v.camera.Offset.X)*v.camera.Zoom, (e.P.Y-v.camera.Offset.Y)*v.camera.Zoom, e.R*v.camera.Zoom+4, 0, math.Pi*2)
+	ctx.Stroke()
+	ctx.ClosePath()
+	ctx.Pop(draw.StrokeStyle)
+}
+
+// cycleBoundaryMode switches the simulation to the next boundary mode.
+func (v *View) cycleBoundaryMode() {
+	mode := v.simulation.BoundaryMode() + 1
+	if mode > universe.BoundaryModeAbsorb {
+		mode = universe.BoundaryModeOpen
+	}
+
+	v.simulation.SetBoundaryMode(mode)
+	fmt.Println("boundary mode", mode)
+}
+
 func (v *View) modifyZoomLevel(in bool) {
 	if !in {
 		v.camera.Zoom /= 2
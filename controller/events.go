@@ -1,3 +1,5 @@
+// +build js
+
 package controller
 
 import (
@@ -10,7 +12,7 @@ import (
 )
 
 type MouseHandler interface {
-	Draw(v *View, ctx *draw.Context)
+	Draw(v *View, ctx draw.Canvas)
 	Move(v *View, xy internal.Vector) bool
 	Release(v *View, xy internal.Vector) bool
 }
@@ -25,13 +27,37 @@ func (v *View) onKeypressEventHandler() js.Func {
 		case ' ':
 			v.paused = !v.paused
 		case 'r':
-			v.entities.Clear()
+			v.simulation.Clear()
 		case 's':
-			*v.entities = append(*v.entities, generateRandomField(1024)(v.box)...)
+			v.simulation.Add(generateRandomField(1024)(v.simulation.Rand(), v.box)...)
 		case 'v':
 			for _, e := range *v.entities {
-				e.V = internal.Vector{}
+				v.simulation.SetVelocity(e, internal.Vector{})
 			}
+		case 'd':
+			v.simulation.SetDeterministic(!v.simulation.Deterministic())
+		case 'c':
+			v.simulation.SetContinuousCollisions(!v.simulation.ContinuousCollisions())
+		case 'b':
+			v.cycleBoundaryMode()
+		case 'f':
+			v.togglePin()
+		case 'n':
+			v.nextScenario()
+		case 'O':
+			v.importScenario()
+		case 'p':
+			v.toggleRecording()
+		case 'P':
+			v.importReplay()
+		case 'e':
+			v.exportSnapshot(universe.SnapshotFormatJson)
+		case 'E':
+			v.exportSnapshot(universe.SnapshotFormatBinary)
+		case 'o':
+			v.importSnapshot()
+		case 'x':
+			v.exportSVG()
 		case '_':
 			v.modifyZoomLevel(false)
 		case '+':
@@ -99,6 +125,7 @@ func (v *View) onMouseDownEventHandler() js.Func {
 
 		target := v.findEntityAtTarget(origin)
 		if target != nil {
+			v.selected = target.ID
 			v.mouseHandler = &VelocityModifier{
 				target:  target,
 				initial: origin,
@@ -164,7 +191,7 @@ func (d *Deleter) box() internal.BoundingBox {
 	}.Abs()
 }
 
-func (d *Deleter) Draw(_ *View, ctx *draw.Context) {
+func (d *Deleter) Draw(_ *View, ctx draw.Canvas) {
 	ctx.BeginPath()
 
 	box := d.box()
@@ -181,7 +208,7 @@ func (d *Deleter) Release(v *View, xy internal.Vector) bool {
 	d.final = xy
 	box := d.box()
 
-	v.entities.DeleteSweep(func(e *universe.Entity) bool {
+	v.simulation.Remove(func(e *universe.Entity) bool {
 		return box.Contains(e.BoundingBox())
 	})
 
@@ -204,7 +231,7 @@ func (vm *VelocityModifier) targetVelocity() internal.Vector {
 	}
 }
 
-func (vm *VelocityModifier) Draw(_ *View, ctx *draw.Context) {
+func (vm *VelocityModifier) Draw(_ *View, ctx draw.Canvas) {
 	ctx.BeginPath()
 	ctx.MoveTo(vm.initial.X, vm.initial.Y)
 	ctx.LineTo(vm.final.X, vm.final.Y)
@@ -221,7 +248,7 @@ func (vm *VelocityModifier) Move(v *View, xy internal.Vector) bool {
 	vm.final = xy
 
 	if v.paused {
-		vm.target.V = vm.targetVelocity()
+		v.simulation.SetVelocity(vm.target, vm.targetVelocity())
 	}
 
 	return false
@@ -229,7 +256,7 @@ func (vm *VelocityModifier) Move(v *View, xy internal.Vector) bool {
 
 func (vm *VelocityModifier) Release(v *View, xy internal.Vector) bool {
 	vm.final = xy
-	vm.target.V = vm.targetVelocity()
+	v.simulation.SetVelocity(vm.target, vm.targetVelocity())
 	return true
 }
 
@@ -258,14 +285,14 @@ func (s *EntitySpawner) radius() float64 {
 	return radius
 }
 
-func (s *EntitySpawner) drawOuterEdge(ctx *draw.Context) {
+func (s *EntitySpawner) drawOuterEdge(ctx draw.Canvas) {
 	ctx.BeginPath()
 	ctx.Arc(s.origin.X, s.origin.Y, s.radius(), 0, math.Pi*2)
 	ctx.Stroke()
 	ctx.ClosePath()
 }
 
-func (s *EntitySpawner) Draw(_ *View, ctx *draw.Context) {
+func (s *EntitySpawner) Draw(_ *View, ctx draw.Canvas) {
 	ctx.BeginPath()
 	ctx.Arc(s.origin.X, s.origin.Y, 1, 0, math.Pi*2)
 	ctx.Fill()
@@ -285,7 +312,7 @@ func (s *EntitySpawner) Move(_ *View, xy internal.Vector) bool {
 
 func (s *EntitySpawner) Release(v *View, xy internal.Vector) bool {
 	s.edge = &xy
-	*v.entities = append(*v.entities, universe.NewEntity(
+	v.simulation.Add(universe.NewEntity(
 		s.origin,
 		internal.Vector{},
 		s.radius(),
@@ -1,13 +1,16 @@
+// +build js
+
 package controller
 
 import (
+	"github.com/relvacode/universe"
 	"github.com/relvacode/universe/draw"
 	"github.com/relvacode/universe/internal"
 )
 
 type Input interface {
 	Update(world internal.BoundingBox) internal.Vector
-	Draw(v *View, ctx *draw.Context)
+	Draw(v *View, ctx draw.Canvas)
 
 	Contains(xy internal.Vector) bool
 	Enabled(v *View) bool
@@ -52,7 +55,7 @@ func (i *InputController) Update(world internal.BoundingBox) {
 	}
 }
 
-func (i *InputController) Draw(v *View, ctx *draw.Context) {
+func (i *InputController) Draw(v *View, ctx draw.Canvas) {
 	for _, input := range i.inputs {
 		input.Draw(v, ctx)
 	}
@@ -80,7 +83,7 @@ func (i *iconButtonInput) Contains(xy internal.Vector) bool {
 	return i.box.ContainsPoint(xy)
 }
 
-func (i *iconButtonInput) drawIcon(ctx *draw.Context, icon string) {
+func (i *iconButtonInput) drawIcon(ctx draw.Canvas, icon string) {
 	ctx.Push(draw.Font, fontSolidIcons)
 	ctx.FillText(icon, i.box.X + 8, i.box.Y + 7)
 	ctx.Pop(draw.Font)
@@ -104,7 +107,7 @@ func (PlayStateInput) iconForState(v *View) string {
 	return iconSolidPause
 }
 
-func (i *PlayStateInput) Draw(v *View, ctx *draw.Context) {
+func (i *PlayStateInput) Draw(v *View, ctx draw.Canvas) {
 	i.drawIcon(ctx, i.iconForState(v))
 }
 
@@ -120,7 +123,7 @@ func (DeleteStateInput) Enabled(v *View) bool {
 	return len(*v.entities) > 0
 }
 
-func (i *DeleteStateInput) Draw(v *View, ctx *draw.Context) {
+func (i *DeleteStateInput) Draw(v *View, ctx draw.Canvas) {
 	if len(*v.entities) == 0 {
 		ctx.Push(draw.GlobalAlpha, .2)
 		defer ctx.Pop(draw.GlobalAlpha)
@@ -130,5 +133,75 @@ func (i *DeleteStateInput) Draw(v *View, ctx *draw.Context) {
 }
 
 func (i *DeleteStateInput) Click(v *View, _ internal.Vector) {
-	v.entities.Clear()
+	v.simulation.Clear()
+}
+
+type BoundaryModeInput struct {
+	iconButtonInput
+}
+
+func (BoundaryModeInput) Enabled(_ *View) bool {
+	return true
+}
+
+func (BoundaryModeInput) iconForState(v *View) string {
+	switch v.simulation.BoundaryMode() {
+	case universe.BoundaryModeReflect:
+		return iconSolidVectorSquare
+	case universe.BoundaryModeWrap:
+		return iconSolidSyncAlt
+	case universe.BoundaryModeAbsorb:
+		return iconSolidSignOutAlt
+	}
+	return iconSolidExpandArrowsAlt
+}
+
+func (i *BoundaryModeInput) Draw(v *View, ctx draw.Canvas) {
+	i.drawIcon(ctx, i.iconForState(v))
+}
+
+func (i *BoundaryModeInput) Click(v *View, _ internal.Vector) {
+	v.cycleBoundaryMode()
+}
+
+type PinStateInput struct {
+	iconButtonInput
+}
+
+func (PinStateInput) Enabled(v *View) bool {
+	return v.selectedEntity() != nil
+}
+
+func (i *PinStateInput) Draw(v *View, ctx draw.Canvas) {
+	e := v.selectedEntity()
+	switch {
+	case e == nil:
+		ctx.Push(draw.GlobalAlpha, .2)
+		defer ctx.Pop(draw.GlobalAlpha)
+	case e.Dynamic():
+		ctx.Push(draw.GlobalAlpha, .6)
+		defer ctx.Pop(draw.GlobalAlpha)
+	}
+
+	i.drawIcon(ctx, iconSolidThumbtack)
+}
+
+func (i *PinStateInput) Click(v *View, _ internal.Vector) {
+	v.togglePin()
+}
+
+type ScenarioInput struct {
+	iconButtonInput
+}
+
+func (ScenarioInput) Enabled(_ *View) bool {
+	return true
+}
+
+func (i *ScenarioInput) Draw(_ *View, ctx draw.Canvas) {
+	i.drawIcon(ctx, iconSolidGlobe)
+}
+
+func (i *ScenarioInput) Click(v *View, _ internal.Vector) {
+	v.nextScenario()
 }
@@ -8,16 +8,18 @@ import (
 	"math/rand"
 )
 
-type EntityPlacer func(box internal.BoundingBox) []*universe.Entity
+// EntityPlacer places new entities within a box.
+// All randomness must be taken from rng so that placement can be reproduced from a seed.
+type EntityPlacer func(rng *rand.Rand, box internal.BoundingBox) []*universe.Entity
 
 func generateRandomField(n int) EntityPlacer {
-	return func(box internal.BoundingBox) (objects []*universe.Entity) {
+	return func(rng *rand.Rand, box internal.BoundingBox) (objects []*universe.Entity) {
 	place:
 		for i := 0; i < n; {
 			en := universe.NewEntity(
 				internal.Vector{
-					X: box.X + (box.W * rand.Float64()),
-					Y: box.Y + (box.H * rand.Float64()),
+					X: box.X + (box.W * rng.Float64()),
+					Y: box.Y + (box.H * rng.Float64()),
 				},
 				internal.Vector{},
 				2,
@@ -1,3 +1,5 @@
+// +build js
+
 package controller
 
 import (
@@ -7,19 +9,34 @@ import (
 	"math"
 )
 
-func (v *View) drawForwardProjections(ctx *draw.Context) {
+func (v *View) drawForwardProjections(ctx draw.Canvas) {
 	if len(*v.entities) == 0 {
 		return
 	}
 
-	copied := make(universe.EntityList, len(*v.entities))
+	simulation := universe.NewSimulation(v.box)
+	simulation.SetCollisionResolver(v.simulation.CollisionResolver())
+	simulation.SetGravity(v.simulation.Gravity())
+	simulation.SetTheta(v.simulation.Theta())
+	simulation.SetIntegrator(v.simulation.Integrator())
+	simulation.SetTimestepMode(v.simulation.TimestepMode())
+	simulation.SetContinuousCollisions(v.simulation.ContinuousCollisions())
+	simulation.SetBoundaryMode(v.simulation.BoundaryMode())
+
+	wraps := simulation.BoundaryMode() == universe.BoundaryModeWrap
+
+	// Projections are kept in the order of the original entities so that they are always drawn in the same order
+	projected := make([]*universe.Entity, 0, len(*v.entities))
 	path := make(map[*universe.Entity][]internal.Vector, len(*v.entities))
 
-	for i, e := range *v.entities {
+	for _, e := range *v.entities {
 		n := &universe.Entity{
 			Object: e.Object,
+			Kind:   e.Kind,
+			Path:   e.Path,
 		}
-		copied[i] = n
+		simulation.Add(n)
+		projected = append(projected, n)
 		path[n] = []internal.Vector{n.P}
 	}
 
@@ -28,25 +45,13 @@ func (v *View) drawForwardProjections(ctx *draw.Context) {
 		return
 	}
 
-	simulation := universe.NewSimulation(v.box)
+	copied := simulation.Entities()
 
 	var it float64
-	for ; it < iterations && len(copied) > 0; it++ {
-		visible, invisible := simulation.CompileTree(copied)
-
-		simulation.Interact(universe.PhysicsConstantTimestep, invisible)
-
-		for _, e := range copied {
-			e.Step(universe.PhysicsConstantTimestep)
-		}
-
-		simulation.ResolveCollisions(visible, v.collisionResolver)
-
-		(&copied).DeleteSweep(func(e *universe.Entity) bool {
-			return e.Disabled
-		})
+	for ; it < iterations && len(*copied) > 0; it++ {
+		simulation.Step(1)
 
-		for _, e := range copied {
+		for _, e := range *copied {
 			if e.V.X == 0 && e.V.Y == 0 {
 				continue
 			}
@@ -55,7 +60,8 @@ func (v *View) drawForwardProjections(ctx *draw.Context) {
 
 	}
 
-	for e, paths := range path {
+	for _, e := range projected {
+		paths := path[e]
 		if len(paths) < 2 {
 			continue
 		}
@@ -69,7 +75,8 @@ func (v *View) drawForwardProjections(ctx *draw.Context) {
 		ctx.BeginPath()
 
 		for i, xy := range paths {
-			if i == 0 {
+			// Start a new line where the path wraps around to the opposite edge of the world
+			if i == 0 || wraps && (math.Abs(xy.X-paths[i-1].X) > v.box.W/2 || math.Abs(xy.Y-paths[i-1].Y) > v.box.H/2) {
 				ctx.MoveTo(xy.X, xy.Y)
 				continue
 			}
@@ -0,0 +1,57 @@
+// +build js
+
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/relvacode/universe"
+	"github.com/relvacode/universe/scenarios"
+)
+
+// setup replaces the simulation with the scenario and moves the camera to the scenario camera.
+func (v *View) setup(scenario *universe.Scenario) error {
+	if err := v.simulation.Setup(scenario); err != nil {
+		return err
+	}
+
+	v.timescale = scenario.TimeScale
+	if v.timescale <= 0 {
+		v.timescale = 1
+	}
+	*v.camera = scenario.Camera.Camera(v.box)
+	v.selected = 0
+
+	fmt.Println("scenario:", scenario.Name)
+	return nil
+}
+
+// nextScenario sets up the bundled scenario after the current one.
+func (v *View) nextScenario() {
+	v.scenario = (v.scenario + 1) % len(scenarios.All)
+
+	scenario, err := scenarios.All[v.scenario].Load()
+	if err != nil {
+		fmt.Println("load scenario:", err)
+		return
+	}
+
+	if err := v.setup(scenario); err != nil {
+		fmt.Println("load scenario:", err)
+	}
+}
+
+// importScenario asks the user for a JSON or YAML scenario file and sets up the simulation from it.
+func (v *View) importScenario() {
+	openFile(".json,.yaml,.yml", func(data []byte) {
+		scenario, err := universe.LoadScenario(bytes.NewReader(data))
+		if err != nil {
+			fmt.Println("import scenario:", err)
+			return
+		}
+
+		if err := v.setup(scenario); err != nil {
+			fmt.Println("import scenario:", err)
+		}
+	})
+}
@@ -0,0 +1,189 @@
+// +build js
+
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/relvacode/universe"
+	"github.com/relvacode/universe/draw"
+	"syscall/js"
+)
+
+func (v *View) snapshot() (*universe.Snapshot, error) {
+	snapshot, err := v.simulation.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.TimeScale = v.timescale
+	snapshot.Camera = *v.camera
+	return snapshot, nil
+}
+
+func (v *View) restore(snapshot *universe.Snapshot) error {
+	if err := v.simulation.Restore(snapshot); err != nil {
+		return err
+	}
+
+	v.timescale = snapshot.TimeScale
+	*v.camera = snapshot.Camera
+	return nil
+}
+
+// exportSnapshot downloads a snapshot of the current simulation.
+func (v *View) exportSnapshot(format universe.SnapshotFormat) {
+	snapshot, err := v.snapshot()
+	if err != nil {
+		fmt.Println("export snapshot:", err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := universe.Save(&buf, snapshot, format); err != nil {
+		fmt.Println("export snapshot:", err)
+		return
+	}
+
+	name, mime := "universe.json", "application/json"
+	if format == universe.SnapshotFormatBinary {
+		name, mime = "universe.bin", "application/octet-stream"
+	}
+
+	download(name, mime, buf.Bytes())
+}
+
+// importSnapshot asks the user for a snapshot file and restores the simulation from it.
+func (v *View) importSnapshot() {
+	openFile(".json,.bin", func(data []byte) {
+		snapshot, err := universe.Load(bytes.NewReader(data))
+		if err != nil {
+			fmt.Println("import snapshot:", err)
+			return
+		}
+
+		if err := v.restore(snapshot); err != nil {
+			fmt.Println("import snapshot:", err)
+		}
+	})
+}
+
+// exportSVG downloads the current view of the simulation as an SVG image.
+func (v *View) exportSVG() {
+	svg := draw.NewSVG(v.box.W, v.box.H)
+	svg.Push(draw.FillStyle, colorBackground)
+	svg.BeginPath()
+	svg.Rect(0, 0, v.box.W, v.box.H)
+	svg.Fill()
+	svg.Pop(draw.FillStyle)
+
+	svg.Push(draw.FillStyle, colorEntity)
+	svg.Push(draw.StrokeStyle, colorEntity)
+	svg.Push(draw.LineWidth, 1)
+
+	universe.DrawEntities(svg, *v.entities, *v.camera, v.box)
+	if len(*v.entities) < 256 {
+		v.drawForwardProjections(svg)
+	}
+
+	var buf bytes.Buffer
+	if _, err := svg.WriteTo(&buf); err != nil {
+		fmt.Println("export svg:", err)
+		return
+	}
+
+	download("universe.svg", "image/svg+xml", buf.Bytes())
+}
+
+// toggleRecording starts recording a replay of the simulation,
+// or stops the current recording and downloads it.
+func (v *View) toggleRecording() {
+	if !v.simulation.Recording() {
+		if err := v.simulation.StartRecording(); err != nil {
+			fmt.Println("record replay:", err)
+		}
+		return
+	}
+
+	replay, err := v.simulation.StopRecording()
+	if err != nil {
+		fmt.Println("record replay:", err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := universe.SaveReplay(&buf, replay); err != nil {
+		fmt.Println("record replay:", err)
+		return
+	}
+
+	download("replay.json", "application/json", buf.Bytes())
+}
+
+// importReplay asks the user for a replay file and plays it from its initial state.
+func (v *View) importReplay() {
+	openFile(".json", func(data []byte) {
+		replay, err := universe.LoadReplay(bytes.NewReader(data))
+		if err != nil {
+			fmt.Println("import replay:", err)
+			return
+		}
+
+		if err := v.simulation.Play(replay); err != nil {
+			fmt.Println("import replay:", err)
+		}
+	})
+}
+
+func download(name, mime string, data []byte) {
+	document := js.Global().Get("document")
+
+	array := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(array, data)
+
+	blob := js.Global().Get("Blob").New([]interface{}{array}, map[string]interface{}{
+		"type": mime,
+	})
+
+	url := js.Global().Get("URL").Call("createObjectURL", blob)
+	defer js.Global().Get("URL").Call("revokeObjectURL", url)
+
+	link := document.Call("createElement", "a")
+	link.Set("href", url)
+	link.Set("download", name)
+	link.Call("click")
+}
+
+func openFile(accept string, f func(data []byte)) {
+	input := js.Global().Get("document").Call("createElement", "input")
+	input.Set("type", "file")
+	input.Set("accept", accept)
+
+	var onChange, onLoad js.Func
+	onLoad = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		defer onLoad.Release()
+
+		array := js.Global().Get("Uint8Array").New(args[0])
+		data := make([]byte, array.Get("length").Int())
+		js.CopyBytesToGo(data, array)
+
+		f(data)
+		return nil
+	})
+
+	onChange = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		defer onChange.Release()
+
+		files := input.Get("files")
+		if files.Get("length").Int() == 0 {
+			onLoad.Release()
+			return nil
+		}
+
+		files.Index(0).Call("arrayBuffer").Call("then", onLoad)
+		return nil
+	})
+
+	input.Call("addEventListener", "change", onChange)
+	input.Call("click")
+}
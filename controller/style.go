@@ -3,6 +3,10 @@ package controller
 const (
 	colorDanger  = "rgba(255, 0, 0, 0.6)"
 	colorDefault = "rgba(0, 179, 255, 0.5)"
+	colorPinned  = "rgba(255, 200, 0, 0.6)"
+
+	colorEntity     = "#00b3ff"
+	colorBackground = "#000000"
 
 	fontInterface = "normal 900 18px sans-serif"
 )
@@ -13,4 +17,11 @@ const (
 	iconSolidPlay  = string(rune(0xf04b))
 	iconSolidPause = string(rune(0xf04c))
 	iconSolidTrash = string(rune(0xf1f8))
+
+	iconSolidExpandArrowsAlt = string(rune(0xf31e))
+	iconSolidVectorSquare    = string(rune(0xf5cb))
+	iconSolidSyncAlt         = string(rune(0xf2f1))
+	iconSolidSignOutAlt      = string(rune(0xf2f5))
+	iconSolidThumbtack       = string(rune(0xf08d))
+	iconSolidGlobe           = string(rune(0xf0ac))
 )
@@ -0,0 +1,150 @@
+package universe
+
+import (
+	"github.com/relvacode/universe/internal"
+	"math"
+)
+
+// Diagnostics contains the conserved quantities of a simulation at a point in time.
+type Diagnostics struct {
+	Entities int
+	Mass     float64
+
+	KineticEnergy   float64
+	PotentialEnergy float64
+
+	CenterOfMass    internal.Vector
+	Momentum        internal.Vector
+	AngularMomentum float64
+}
+
+// Energy is the total kinetic and potential energy.
+func (d Diagnostics) Energy() float64 {
+	return d.KineticEnergy + d.PotentialEnergy
+}
+
+// Drift calculates the relative change in energy and angular momentum from an earlier baseline.
+// Momentum drift is the magnitude of the absolute change in momentum because a system at rest has no total momentum.
+func (d Diagnostics) Drift(baseline Diagnostics) (energy, momentum, angularMomentum float64) {
+	relative := func(v, base float64) float64 {
+		if base == 0 {
+			return v - base
+		}
+		return (v - base) / math.Abs(base)
+	}
+
+	delta := internal.Vector{
+		X: d.Momentum.X - baseline.Momentum.X,
+		Y: d.Momentum.Y - baseline.Momentum.Y,
+	}
+
+	return relative(d.Energy(), baseline.Energy()),
+		math.Sqrt(delta.Dot()),
+		relative(d.AngularMomentum, baseline.AngularMomentum)
+}
+
+// ComputeDiagnostics calculates the total energy, momentum and angular momentum of all entities in the simulation.
+// Potential energy is calculated from the simulation's force law using the same approximation as gravity.
+// Angular momentum is taken about the center of mass.
+func (s *Simulation) ComputeDiagnostics() Diagnostics {
+	var d Diagnostics
+	if len(s.entities) == 0 {
+		return d
+	}
+
+	s.syncEntities()
+
+	s.compile()
+
+	var massVector internal.Vector
+	for _, e := range s.compiled {
+		if e.Disabled {
+			continue
+		}
+
+		d.Entities++
+		d.Mass += e.M
+		d.KineticEnergy += e.KineticEnergy()
+
+		momentum := e.Momentum()
+		d.Momentum.X += momentum.X
+		d.Momentum.Y += momentum.Y
+
+		massVector.X += e.P.X * e.M
+		massVector.Y += e.P.Y * e.M
+	}
+
+	if cap(s.potentials) < len(s.compiled) {
+		s.potentials = make([]float64, len(s.compiled))
+	}
+	s.potentials = s.potentials[:len(s.compiled)]
+
+	parallel(s.workers, len(s.compiled), func(start, end int) {
+		for i := start; i < end; i++ {
+			e := s.compiled[i]
+			if e.Disabled {
+				continue
+			}
+
+			s.potentials[i] = s.tree.Potential(e, s.theta, s.gravity)
+		}
+	})
+
+	// Potentials are summed in order so that the total does not depend on how work was split between workers
+	for i, e := range s.compiled {
+		if e.Disabled {
+			continue
+		}
+
+		// Each pair is counted from both sides
+		d.PotentialEnergy += 0.5 * e.M * s.potentials[i]
+	}
+
+	if d.Mass == 0 {
+		return d
+	}
+
+	d.CenterOfMass = internal.Vector{
+		X: massVector.X / d.Mass,
+		Y: massVector.Y / d.Mass,
+	}
+
+	for _, e := range s.compiled {
+		if e.Disabled {
+			continue
+		}
+
+		momentum := e.Momentum()
+		d.AngularMomentum += (e.P.X-d.CenterOfMass.X)*momentum.Y - (e.P.Y-d.CenterOfMass.Y)*momentum.X
+	}
+
+	return d
+}
+
+// Diagnostics returns the diagnostics calculated at the end of the most recent step
+// and the baseline they are compared against to measure drift.
+// Diagnostics are only calculated when enabled with SetTrackDiagnostics.
+func (s *Simulation) Diagnostics() (current, baseline Diagnostics) {
+	return s.diagnostics, s.diagnosticsBaseline
+}
+
+// SetTrackDiagnostics enables or disables calculating diagnostics at the end of every step.
+func (s *Simulation) SetTrackDiagnostics(enabled bool) {
+	s.trackDiagnostics = enabled
+	s.ResetDiagnostics()
+}
+
+// ResetDiagnostics sets the drift baseline to the diagnostics calculated at the end of the next step.
+func (s *Simulation) ResetDiagnostics() {
+	s.diagnosticsBaseline = Diagnostics{}
+	s.diagnostics = Diagnostics{}
+}
+
+func (s *Simulation) updateDiagnostics() {
+	s.diagnostics = s.ComputeDiagnostics()
+
+	// Entities added since the baseline would appear as drift
+	if s.diagnostics.Entities > s.diagnosticsBaseline.Entities {
+		s.diagnosticsBaseline = s.diagnostics
+	}
+}
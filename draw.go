@@ -0,0 +1,103 @@
+package universe
+
+import (
+	"github.com/relvacode/universe/draw"
+	"github.com/relvacode/universe/internal"
+	"math"
+)
+
+// DrawEntities draws every entity that is visible through the camera.
+// The world boundary is the area of the canvas the camera is fitted to.
+func DrawEntities(ctx draw.Canvas, entities EntityList, c draw.Camera, worldBoundary internal.BoundingBox) {
+	cameraBounds := c.Crop(worldBoundary)
+
+	for _, e := range entities {
+		// Do not draw entities that are not within the bounds of the current camera
+		if !cameraBounds.Intersects(e.BoundingBox()) {
+			continue
+		}
+
+		e.Draw(ctx, c)
+	}
+}
+
+// DrawParticles draws every particle in the store that is visible through the camera.
+// The metadata of each particle is taken from the entity at the same index in the list.
+func DrawParticles(ctx draw.Canvas, ps *ParticleStore, entities EntityList, c draw.Camera, worldBoundary internal.BoundingBox) {
+	cameraBounds := c.Crop(worldBoundary)
+
+	for i := 0; i < ps.Len(); i++ {
+		if !cameraBounds.Intersects(ps.BoundingBox(i)) {
+			continue
+		}
+
+		drawBody(ctx, c, internal.Vector{X: ps.X[i], Y: ps.Y[i]}, ps.R[i], entities[i].Metadata)
+	}
+}
+
+// Draw draws every entity in the simulation that is visible through the camera.
+// The world boundary of the simulation is the area of the canvas the camera is fitted to.
+// While the particle store holds the state of the simulation entities are drawn from their particles.
+func (s *Simulation) Draw(ctx draw.Canvas, c draw.Camera) {
+	if s.particlesCurrent {
+		DrawParticles(ctx, s.particles, s.entities, c, s.worldBoundary)
+		return
+	}
+
+	DrawEntities(ctx, s.entities, c, s.worldBoundary)
+}
+
+// labelMargin is the gap between an entity and its label.
+const labelMargin = 4
+
+// Draw draws the entity in the current fill style, or in the colour of its metadata.
+// Entities with a name are labelled to their right.
+func (e *Entity) Draw(ctx draw.Canvas, c draw.Camera) {
+	drawBody(ctx, c, e.P, e.R, e.Metadata)
+}
+
+// drawBody draws a body with the given position, radius and metadata.
+func drawBody(ctx draw.Canvas, c draw.Camera, p internal.Vector, radius float64, metadata *Metadata) {
+	if metadata != nil && metadata.Color != "" {
+		ctx.Push(draw.FillStyle, metadata.Color)
+		defer ctx.Pop(draw.FillStyle)
+	}
+
+	x, y, r := (p.X-c.Offset.X)*c.Zoom, (p.Y-c.Offset.Y)*c.Zoom, radius*c.Zoom
+
+	ctx.BeginPath()
+	ctx.Arc(x, y, r, 0, 2*math.Pi)
+	ctx.Fill()
+	ctx.ClosePath()
+
+	if metadata != nil && metadata.Name != "" {
+		ctx.Push(draw.TextBaseline, "middle")
+		ctx.FillText(metadata.Name, x+r+labelMargin, y)
+		ctx.Pop(draw.TextBaseline)
+	}
+}
+
+func (qt *QuadTree) Draw(ctx draw.Canvas) {
+	if len(qt.objects) > 0 {
+		ctx.BeginPath()
+		ctx.MoveTo(qt.boundary.X, qt.boundary.Y)
+		ctx.LineTo(qt.boundary.X+qt.boundary.W, qt.boundary.Y)
+		ctx.LineTo(qt.boundary.X+qt.boundary.W, qt.boundary.Y+qt.boundary.H)
+		ctx.LineTo(qt.boundary.X, qt.boundary.Y+qt.boundary.H)
+		ctx.LineTo(qt.boundary.X, qt.boundary.Y)
+		ctx.Stroke()
+
+		ctx.BeginPath()
+
+		c := qt.CenterOfMass()
+		ctx.Arc(c.X, c.Y, math.Cbrt(qt.totalMass), 0, math.Pi*2)
+		ctx.Stroke()
+	}
+
+	if qt.nw != nil {
+		qt.nw.Draw(ctx)
+		qt.ne.Draw(ctx)
+		qt.sw.Draw(ctx)
+		qt.se.Draw(ctx)
+	}
+}
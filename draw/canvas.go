@@ -1,3 +1,5 @@
+// +build js
+
 package draw
 
 import (
@@ -5,7 +7,13 @@ import (
 	"syscall/js"
 )
 
-//
+func (attr *styleAttribute) apply(ctx js.Value) {
+	//if attr.parent != nil && attr.value.Equal(attr.parent.value) {
+	//	// Do not apply if this value is the same as its parent
+	//	return
+	//}
+	ctx.Set(attr.attr.String(), attr.value)
+}
 
 func GetContext(el js.Value) *Context {
 	var state styleStateMachine
@@ -15,6 +23,8 @@ func GetContext(el js.Value) *Context {
 	}
 }
 
+var _ Canvas = (*Context)(nil)
+
 type Context struct {
 	c           js.Value
 	state       *styleStateMachine
@@ -33,11 +43,11 @@ func (ctx *Context) Resize(w, h float64) {
 	}
 }
 
-func (ctx *Context) Push(attr attribute, value interface{}) {
+func (ctx *Context) Push(attr Attribute, value interface{}) {
 	ctx.state.push(attr, value).apply(ctx.c)
 }
 
-func (ctx *Context) Pop(attr attribute) {
+func (ctx *Context) Pop(attr Attribute) {
 	n := ctx.state.pop(attr)
 	if n == nil {
 		ctx.c.Set(attr.String(), nil)
@@ -47,7 +57,7 @@ func (ctx *Context) Pop(attr attribute) {
 	n.apply(ctx.c)
 }
 
-func (ctx *Context) Get(attr attribute) js.Value {
+func (ctx *Context) Get(attr Attribute) js.Value {
 	return ctx.c.Get(attr.String())
 }
 
@@ -0,0 +1,113 @@
+package draw
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+var namedColors = map[string]color.NRGBA{
+	"black":       {0, 0, 0, 255},
+	"white":       {255, 255, 255, 255},
+	"red":         {255, 0, 0, 255},
+	"green":       {0, 128, 0, 255},
+	"blue":        {0, 0, 255, 255},
+	"yellow":      {255, 255, 0, 255},
+	"gray":        {128, 128, 128, 255},
+	"grey":        {128, 128, 128, 255},
+	"transparent": {0, 0, 0, 0},
+}
+
+// ParseColor parses a CSS colour as used by the fillStyle and strokeStyle attributes.
+// Hex (#rgb, #rrggbb, #rrggbbaa), rgb(), rgba() and a few named colours are supported.
+// A color.Color is returned unchanged.
+func ParseColor(v interface{}) (color.Color, error) {
+	switch v := v.(type) {
+	case color.Color:
+		return v, nil
+	case string:
+		return parseColorString(strings.ToLower(strings.TrimSpace(v)))
+	}
+
+	return nil, fmt.Errorf("unsupported colour %v", v)
+}
+
+func parseColorString(s string) (color.Color, error) {
+	if c, ok := namedColors[s]; ok {
+		return c, nil
+	}
+
+	if strings.HasPrefix(s, "#") {
+		return parseHexColor(s[1:])
+	}
+
+	for _, fn := range []string{"rgba(", "rgb("} {
+		if strings.HasPrefix(s, fn) && strings.HasSuffix(s, ")") {
+			return parseFunctionalColor(s[len(fn) : len(s)-1])
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported colour %q", s)
+}
+
+func parseHexColor(s string) (color.Color, error) {
+	switch len(s) {
+	case 3:
+		// Expand shorthand #rgb into #rrggbb
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	case 6, 8:
+	default:
+		return nil, fmt.Errorf("invalid hex colour #%s", s)
+	}
+
+	if len(s) == 6 {
+		s += "ff"
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex colour #%s", s)
+	}
+
+	return color.NRGBA{
+		R: uint8(v >> 24),
+		G: uint8(v >> 16),
+		B: uint8(v >> 8),
+		A: uint8(v),
+	}, nil
+}
+
+func parseFunctionalColor(s string) (color.Color, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 && len(parts) != 4 {
+		return nil, fmt.Errorf("invalid colour components %q", s)
+	}
+
+	var c [4]float64
+	c[3] = 1
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid colour component %q", part)
+		}
+		c[i] = v
+	}
+
+	clamp := func(v, max float64) uint8 {
+		if v < 0 {
+			return 0
+		}
+		if v > max {
+			v = max
+		}
+		return uint8(v / max * 255)
+	}
+
+	return color.NRGBA{
+		R: clamp(c[0], 255),
+		G: clamp(c[1], 255),
+		B: clamp(c[2], 255),
+		A: clamp(c[3], 1),
+	}, nil
+}
@@ -0,0 +1,58 @@
+package draw
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  color.Color
+	}{
+		{"hex", "#ff8000", color.NRGBA{255, 128, 0, 255}},
+		{"hex shorthand", "#f80", color.NRGBA{255, 136, 0, 255}},
+		{"hex alpha", "#ff800080", color.NRGBA{255, 128, 0, 128}},
+		{"hex upper case", "#FF8000", color.NRGBA{255, 128, 0, 255}},
+		{"rgb", "rgb(255, 128, 0)", color.NRGBA{255, 128, 0, 255}},
+		{"rgba", "rgba(255, 128, 0, 0.5)", color.NRGBA{255, 128, 0, 127}},
+		{"rgb out of range", "rgb(300, -10, 0)", color.NRGBA{255, 0, 0, 255}},
+		{"named", "white", color.NRGBA{255, 255, 255, 255}},
+		{"named with space", " Grey ", color.NRGBA{128, 128, 128, 255}},
+		{"transparent", "transparent", color.NRGBA{}},
+		{"color", color.RGBA{1, 2, 3, 4}, color.RGBA{1, 2, 3, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseColor(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseColor(%q) = %v, expected %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseColorInvalid(t *testing.T) {
+	for _, v := range []interface{}{
+		"",
+		"#ff",
+		"#ff80",
+		"#gggggg",
+		"rgb(1, 2)",
+		"rgb(1, 2, x)",
+		"rgba(1, 2, 3, 4, 5)",
+		"rgb(1, 2, 3",
+		"purple",
+		42,
+		nil,
+	} {
+		if c, err := ParseColor(v); err == nil {
+			t.Errorf("ParseColor(%#v) = %v, expected an error", v, c)
+		}
+	}
+}
@@ -4,10 +4,6 @@ import (
 	"github.com/relvacode/universe/internal"
 )
 
-type Renderer interface {
-	Draw(ctx *Context, c *Camera)
-}
-
 type Camera struct {
 	Zoom   float64
 	Offset internal.Vector
@@ -25,4 +21,29 @@ func (c Camera) Crop(global internal.BoundingBox) internal.BoundingBox {
 func (c *Camera) FitCenter(global internal.BoundingBox) {
 	c.Offset.X = (global.W - (global.W / c.Zoom)) / 2
 	c.Offset.Y = (global.H - (global.H / c.Zoom)) / 2
-}
\ No newline at end of file
+}
+
+// Canvas is a 2D drawing surface modelled on the HTML canvas 2D context.
+// Styles are applied with Push and restored to their previous value with Pop.
+//
+// Context draws to a browser canvas, Raster to an image, SVG to a vector document
+// and Recorder records each call for inspection in tests.
+type Canvas interface {
+	Push(attr Attribute, value interface{})
+	Pop(attr Attribute)
+
+	ClearRect(x, y, w, h float64)
+
+	BeginPath()
+	ClosePath()
+	MoveTo(x, y float64)
+	LineTo(x, y float64)
+	Rect(x, y, w, h float64)
+	Arc(x, y, radius, startAngle, endAngle float64)
+	Fill()
+	Stroke()
+
+	FillText(text string, x, y float64)
+	MeasureTextWidth(text string) float64
+	MeasureFontHeight() float64
+}
@@ -0,0 +1,373 @@
+package draw
+
+import (
+	"image"
+	"image/color"
+	stddraw "image/draw"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// rasterCoordinateLimit bounds path coordinates so that far away geometry does not overflow the rasterizer.
+const rasterCoordinateLimit = 1 << 20
+
+type rasterPoint struct {
+	x, y float64
+}
+
+type rasterPath struct {
+	points []rasterPoint
+	closed bool
+}
+
+// Raster is a pure-Go Canvas that draws into an image.RGBA.
+// Paths are anti-aliased; text is drawn with a fixed-size bitmap font regardless of the font attribute.
+type Raster struct {
+	img   *image.RGBA
+	state styleStateMachine
+	face  font.Face
+
+	paths []rasterPath
+
+	// z is reused by every fill and stroke, covering only the clip rectangle of the image
+	z    vector.Rasterizer
+	clip image.Rectangle
+
+	// err is the first colour that could not be parsed
+	err error
+}
+
+var _ Canvas = (*Raster)(nil)
+
+// NewRaster creates a transparent raster of the given size.
+func NewRaster(w, h int) *Raster {
+	return &Raster{
+		img:  image.NewRGBA(image.Rect(0, 0, w, h)),
+		face: basicfont.Face7x13,
+	}
+}
+
+// Image returns the image the raster draws into.
+func (r *Raster) Image() *image.RGBA {
+	return r.img
+}
+
+// Err returns the first error encountered while drawing, such as an unsupported colour.
+// Drawing continues after an error, using black in place of the unsupported colour.
+func (r *Raster) Err() error {
+	return r.err
+}
+
+func (r *Raster) Push(attr Attribute, value interface{}) {
+	r.state.push(attr, value)
+}
+
+func (r *Raster) Pop(attr Attribute) {
+	r.state.pop(attr)
+}
+
+func (r *Raster) value(attr Attribute) interface{} {
+	if s := r.state[attr]; s != nil {
+		return s.value
+	}
+	return nil
+}
+
+func (r *Raster) float(attr Attribute, fallback float64) float64 {
+	switch v := r.value(attr).(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	case int:
+		return float64(v)
+	}
+	return fallback
+}
+
+// color returns the colour of a style attribute with the global alpha applied.
+// The default colour of a canvas is black, which is also used if the colour cannot be parsed.
+func (r *Raster) color(attr Attribute) color.Color {
+	var c color.Color = color.Black
+	if v := r.value(attr); v != nil {
+		parsed, err := ParseColor(v)
+		if err != nil {
+			if r.err == nil {
+				r.err = err
+			}
+		} else {
+			c = parsed
+		}
+	}
+
+	alpha := r.float(GlobalAlpha, 1)
+	if alpha >= 1 {
+		return c
+	}
+
+	nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+	nc.A = uint8(float64(nc.A) * math.Max(alpha, 0))
+	return nc
+}
+
+func (r *Raster) ClearRect(x, y, w, h float64) {
+	rect := image.Rect(int(math.Floor(x)), int(math.Floor(y)), int(math.Ceil(x+w)), int(math.Ceil(y+h)))
+	stddraw.Draw(r.img, rect, image.Transparent, image.Point{}, stddraw.Src)
+}
+
+func (r *Raster) BeginPath() {
+	r.paths = r.paths[:0]
+}
+
+func (r *Raster) current() *rasterPath {
+	if len(r.paths) == 0 {
+		r.paths = append(r.paths, rasterPath{})
+	}
+	return &r.paths[len(r.paths)-1]
+}
+
+func (r *Raster) ClosePath() {
+	if len(r.paths) == 0 {
+		return
+	}
+
+	p := r.current()
+	if len(p.points) == 0 {
+		return
+	}
+
+	p.closed = true
+
+	// Start a new sub-path at the start of the closed path
+	r.paths = append(r.paths, rasterPath{
+		points: []rasterPoint{p.points[0]},
+	})
+}
+
+func (r *Raster) MoveTo(x, y float64) {
+	r.paths = append(r.paths, rasterPath{
+		points: []rasterPoint{{x, y}},
+	})
+}
+
+func (r *Raster) LineTo(x, y float64) {
+	p := r.current()
+	p.points = append(p.points, rasterPoint{x, y})
+}
+
+func (r *Raster) Rect(x, y, w, h float64) {
+	r.MoveTo(x, y)
+	r.LineTo(x+w, y)
+	r.LineTo(x+w, y+h)
+	r.LineTo(x, y+h)
+	r.current().closed = true
+	r.MoveTo(x, y)
+}
+
+func (r *Raster) Arc(x, y, radius, startAngle, endAngle float64) {
+	sweep := endAngle - startAngle
+	if sweep > 2*math.Pi {
+		sweep = 2 * math.Pi
+	}
+
+	// Flatten the arc into segments no longer than a couple of pixels
+	n := int(math.Ceil(math.Abs(sweep) * radius / 2))
+	if n < 8 {
+		n = 8
+	}
+	if n > 512 {
+		n = 512
+	}
+
+	for i := 0; i <= n; i++ {
+		a := startAngle + sweep*float64(i)/float64(n)
+		r.LineTo(x+radius*math.Cos(a), y+radius*math.Sin(a))
+	}
+}
+
+func clampCoordinate(v float64) float32 {
+	return float32(math.Max(-rasterCoordinateLimit, math.Min(rasterCoordinateLimit, v)))
+}
+
+// rasterBounds is the bounding box of the geometry of a fill or stroke.
+type rasterBounds struct {
+	minX, minY, maxX, maxY float64
+	empty                  bool
+}
+
+func newRasterBounds() rasterBounds {
+	return rasterBounds{
+		minX: math.Inf(1), minY: math.Inf(1),
+		maxX: math.Inf(-1), maxY: math.Inf(-1),
+		empty: true,
+	}
+}
+
+func (b *rasterBounds) add(pt rasterPoint) {
+	b.minX, b.minY = math.Min(b.minX, pt.x), math.Min(b.minY, pt.y)
+	b.maxX, b.maxY = math.Max(b.maxX, pt.x), math.Max(b.maxY, pt.y)
+	b.empty = false
+}
+
+// begin resets the rasterizer to the part of the image covered by the bounds, grown by pad on each side.
+// Returns false if the bounds are empty or outside of the image.
+func (r *Raster) begin(b rasterBounds, pad float64) bool {
+	if b.empty {
+		return false
+	}
+
+	rect := image.Rect(
+		int(math.Floor(float64(clampCoordinate(b.minX-pad)))),
+		int(math.Floor(float64(clampCoordinate(b.minY-pad)))),
+		int(math.Ceil(float64(clampCoordinate(b.maxX+pad)))),
+		int(math.Ceil(float64(clampCoordinate(b.maxY+pad)))),
+	)
+
+	r.clip = rect.Intersect(r.img.Bounds())
+	if r.clip.Empty() {
+		return false
+	}
+
+	r.z.Reset(r.clip.Dx(), r.clip.Dy())
+	return true
+}
+
+// moveTo and lineTo add a point to the rasterizer relative to the clip rectangle.
+func (r *Raster) moveTo(x, y float64) {
+	r.z.MoveTo(clampCoordinate(x-float64(r.clip.Min.X)), clampCoordinate(y-float64(r.clip.Min.Y)))
+}
+
+func (r *Raster) lineTo(x, y float64) {
+	r.z.LineTo(clampCoordinate(x-float64(r.clip.Min.X)), clampCoordinate(y-float64(r.clip.Min.Y)))
+}
+
+func (r *Raster) draw(attr Attribute) {
+	r.z.Draw(r.img, r.clip, image.NewUniform(r.color(attr)), image.Point{})
+}
+
+func (r *Raster) Fill() {
+	b := newRasterBounds()
+	for _, p := range r.paths {
+		if len(p.points) < 3 {
+			continue
+		}
+		for _, pt := range p.points {
+			b.add(pt)
+		}
+	}
+
+	if !r.begin(b, 1) {
+		return
+	}
+
+	for _, p := range r.paths {
+		if len(p.points) < 3 {
+			continue
+		}
+
+		r.moveTo(p.points[0].x, p.points[0].y)
+		for _, pt := range p.points[1:] {
+			r.lineTo(pt.x, pt.y)
+		}
+		r.z.ClosePath()
+	}
+
+	r.draw(FillStyle)
+}
+
+func (r *Raster) Stroke() {
+	hw := math.Max(r.float(LineWidth, 1), 1) / 2
+
+	b := newRasterBounds()
+	for _, p := range r.paths {
+		if len(p.points) < 2 {
+			continue
+		}
+		for _, pt := range p.points {
+			b.add(pt)
+		}
+	}
+
+	// The corners of a segment are at most half the line width from its end along and across the segment
+	if !r.begin(b, hw*math.Sqrt2+1) {
+		return
+	}
+
+	var empty = true
+	segment := func(a, b rasterPoint, capA, capB bool) {
+		dx, dy := b.x-a.x, b.y-a.y
+		l := math.Hypot(dx, dy)
+		if l == 0 {
+			return
+		}
+
+		empty = false
+
+		ux, uy := dx/l*hw, dy/l*hw
+		nx, ny := -uy, ux
+
+		// Extend the open ends of a path by half the line width
+		var ax, ay, bx, by = a.x, a.y, b.x, b.y
+		if capA {
+			ax, ay = ax-ux, ay-uy
+		}
+		if capB {
+			bx, by = bx+ux, by+uy
+		}
+
+		r.moveTo(ax+nx, ay+ny)
+		r.lineTo(bx+nx, by+ny)
+		r.lineTo(bx-nx, by-ny)
+		r.lineTo(ax-nx, ay-ny)
+		r.z.ClosePath()
+	}
+
+	for _, p := range r.paths {
+		n := len(p.points)
+		closed := p.closed && n > 2
+		for i := 1; i < n; i++ {
+			segment(p.points[i-1], p.points[i], i == 1 && !closed, i == n-1 && !closed)
+		}
+		if closed {
+			segment(p.points[n-1], p.points[0], false, false)
+		}
+	}
+
+	if !empty {
+		r.draw(StrokeStyle)
+	}
+}
+
+func (r *Raster) FillText(text string, x, y float64) {
+	metrics := r.face.Metrics()
+
+	// Position the dot on the alphabetic baseline
+	switch r.value(TextBaseline) {
+	case "top", "hanging":
+		y += float64(metrics.Ascent.Ceil())
+	case "middle":
+		y += float64(metrics.Ascent.Ceil()-metrics.Descent.Ceil()) / 2
+	case "bottom", "ideographic":
+		y -= float64(metrics.Descent.Ceil())
+	}
+
+	d := font.Drawer{
+		Dst:  r.img,
+		Src:  image.NewUniform(r.color(FillStyle)),
+		Face: r.face,
+		Dot:  fixed.P(int(math.Round(x)), int(math.Round(y))),
+	}
+	d.DrawString(text)
+}
+
+func (r *Raster) MeasureTextWidth(text string) float64 {
+	return float64(font.MeasureString(r.face, text).Ceil())
+}
+
+func (r *Raster) MeasureFontHeight() float64 {
+	return float64(r.face.Metrics().Height.Ceil())
+}
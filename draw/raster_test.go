@@ -0,0 +1,85 @@
+package draw
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+var (
+	rasterOpaque      = color.RGBA{255, 0, 0, 255}
+	rasterTransparent = color.RGBA{}
+)
+
+// assertPixels checks the colour of each pixel of the raster at the given points.
+func assertPixels(t *testing.T, r *Raster, want color.RGBA, points ...image.Point) {
+	t.Helper()
+
+	for _, pt := range points {
+		if got := r.Image().RGBAAt(pt.X, pt.Y); got != want {
+			t.Errorf("pixel %v is %v, expected %v", pt, got, want)
+		}
+	}
+}
+
+func TestRasterFill(t *testing.T) {
+	r := NewRaster(10, 10)
+	r.Push(FillStyle, "#ff0000")
+	r.BeginPath()
+	r.Rect(2, 2, 6, 6)
+	r.Fill()
+
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	assertPixels(t, r, rasterOpaque, image.Pt(2, 2), image.Pt(5, 5), image.Pt(7, 7))
+	assertPixels(t, r, rasterTransparent, image.Pt(1, 1), image.Pt(8, 5), image.Pt(5, 8))
+}
+
+func TestRasterFillClipped(t *testing.T) {
+	r := NewRaster(10, 10)
+	r.Push(FillStyle, "red")
+
+	// A circle around the corner of the image is only drawn where it overlaps the image
+	r.BeginPath()
+	r.Arc(0, 0, 5, 0, 2*math.Pi)
+	r.Fill()
+
+	// Geometry entirely outside of the image draws nothing
+	r.BeginPath()
+	r.Rect(-20, -20, 5, 5)
+	r.Fill()
+
+	assertPixels(t, r, rasterOpaque, image.Pt(0, 0), image.Pt(2, 2))
+	assertPixels(t, r, rasterTransparent, image.Pt(5, 5), image.Pt(9, 0), image.Pt(0, 9))
+}
+
+func TestRasterStroke(t *testing.T) {
+	r := NewRaster(10, 10)
+	r.Push(StrokeStyle, "rgb(255, 0, 0)")
+	r.Push(LineWidth, 2.0)
+	r.BeginPath()
+	r.MoveTo(2, 5)
+	r.LineTo(8, 5)
+	r.Stroke()
+
+	// The line covers half the line width either side of the path, and its ends are extended by the same amount
+	assertPixels(t, r, rasterOpaque, image.Pt(1, 4), image.Pt(5, 4), image.Pt(5, 5), image.Pt(8, 5))
+	assertPixels(t, r, rasterTransparent, image.Pt(0, 5), image.Pt(9, 5), image.Pt(5, 3), image.Pt(5, 6))
+}
+
+func TestRasterUnsupportedColor(t *testing.T) {
+	r := NewRaster(4, 4)
+	r.Push(FillStyle, "purple")
+	r.BeginPath()
+	r.Rect(0, 0, 4, 4)
+	r.Fill()
+
+	if r.Err() == nil {
+		t.Fatal("expected an error for an unsupported colour")
+	}
+
+	assertPixels(t, r, color.RGBA{0, 0, 0, 255}, image.Pt(1, 1))
+}
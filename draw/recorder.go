@@ -0,0 +1,150 @@
+package draw
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// RecorderCharWidth is the width of every character measured by a Recorder.
+	RecorderCharWidth = 6
+	// RecorderFontHeight is the height of the font measured by a Recorder.
+	RecorderFontHeight = 10
+)
+
+// Call is a single method call made on a Recorder.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+func (c Call) String() string {
+	args := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		switch arg := arg.(type) {
+		case string:
+			args[i] = fmt.Sprintf("%q", arg)
+		case float64:
+			args[i] = formatSVGNumber(arg)
+		default:
+			args[i] = fmt.Sprint(arg)
+		}
+	}
+
+	return c.Method + "(" + strings.Join(args, ", ") + ")"
+}
+
+// Recorder is a Canvas that records every call made on it instead of drawing,
+// so that drawing code can be tested without a browser.
+// Text is measured with a fixed character width and font height.
+type Recorder struct {
+	state styleStateMachine
+	calls []Call
+}
+
+var _ Canvas = (*Recorder)(nil)
+
+func (r *Recorder) record(method string, args ...interface{}) {
+	r.calls = append(r.calls, Call{
+		Method: method,
+		Args:   args,
+	})
+}
+
+// Calls returns every call recorded since the recorder was created or last reset.
+func (r *Recorder) Calls() []Call {
+	return r.calls
+}
+
+// Count returns the number of recorded calls to a method.
+func (r *Recorder) Count(method string) int {
+	var n int
+	for _, c := range r.calls {
+		if c.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+// Style returns the current value of a style attribute, or nil if it has not been pushed.
+func (r *Recorder) Style(attr Attribute) interface{} {
+	if s := r.state[attr]; s != nil {
+		return s.value
+	}
+	return nil
+}
+
+// Reset forgets every recorded call and style.
+func (r *Recorder) Reset() {
+	r.calls = nil
+	r.state = styleStateMachine{}
+}
+
+// String lists every recorded call on its own line.
+func (r *Recorder) String() string {
+	var b strings.Builder
+	for _, c := range r.calls {
+		b.WriteString(c.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func (r *Recorder) Push(attr Attribute, value interface{}) {
+	r.state.push(attr, value)
+	r.record("Push", attr, value)
+}
+
+func (r *Recorder) Pop(attr Attribute) {
+	r.state.pop(attr)
+	r.record("Pop", attr)
+}
+
+func (r *Recorder) ClearRect(x, y, w, h float64) {
+	r.record("ClearRect", x, y, w, h)
+}
+
+func (r *Recorder) BeginPath() {
+	r.record("BeginPath")
+}
+
+func (r *Recorder) ClosePath() {
+	r.record("ClosePath")
+}
+
+func (r *Recorder) MoveTo(x, y float64) {
+	r.record("MoveTo", x, y)
+}
+
+func (r *Recorder) LineTo(x, y float64) {
+	r.record("LineTo", x, y)
+}
+
+func (r *Recorder) Rect(x, y, w, h float64) {
+	r.record("Rect", x, y, w, h)
+}
+
+func (r *Recorder) Arc(x, y, radius, startAngle, endAngle float64) {
+	r.record("Arc", x, y, radius, startAngle, endAngle)
+}
+
+func (r *Recorder) Fill() {
+	r.record("Fill")
+}
+
+func (r *Recorder) Stroke() {
+	r.record("Stroke")
+}
+
+func (r *Recorder) FillText(text string, x, y float64) {
+	r.record("FillText", text, x, y)
+}
+
+func (r *Recorder) MeasureTextWidth(text string) float64 {
+	return RecorderCharWidth * float64(len([]rune(text)))
+}
+
+func (r *Recorder) MeasureFontHeight() float64 {
+	return RecorderFontHeight
+}
@@ -3,11 +3,11 @@ package draw
 import (
 	"fmt"
 	"strings"
-	"syscall/js"
 )
 
 //go:generate go run github.com/abice/go-enum -f=$GOFILE --noprefix
 
+// Attribute is a style attribute of a Canvas that is set with Push and restored with Pop.
 /*
 ENUM(
 strokeStyle,
@@ -19,11 +19,11 @@ globalAlpha
 styleEnumSize
 )
 */
-type attribute uint8
+type Attribute uint8
 
 type styleAttribute struct {
-	attr   attribute
-	value  js.Value
+	attr   Attribute
+	value  interface{}
 	parent *styleAttribute
 }
 
@@ -40,28 +40,20 @@ func (attr *styleAttribute) String() string {
 	return b.String()
 }
 
-func (attr *styleAttribute) apply(ctx js.Value) {
-	//if attr.parent != nil && attr.value.Equal(attr.parent.value) {
-	//	// Do not apply if this value is the same as its parent
-	//	return
-	//}
-	ctx.Set(attr.attr.String(), attr.value)
-}
-
 type styleStateMachine [StyleEnumSize]*styleAttribute
 
-func (state *styleStateMachine) push(attr attribute, value interface{}) *styleAttribute {
+func (state *styleStateMachine) push(attr Attribute, value interface{}) *styleAttribute {
 	parent := state[attr]
 	next := &styleAttribute{
 		attr:   attr,
-		value:  js.ValueOf(value),
+		value:  value,
 		parent: parent,
 	}
 	state[attr] = next
 	return next
 }
 
-func (state *styleStateMachine) pop(attr attribute) *styleAttribute {
+func (state *styleStateMachine) pop(attr Attribute) *styleAttribute {
 	current := state[attr]
 	if current == nil {
 		return nil
@@ -0,0 +1,244 @@
+package draw
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// defaultFontSize is the font size of a canvas when the font attribute does not specify one.
+const defaultFontSize = 10
+
+// SVG is a Canvas that records drawing operations as SVG elements.
+// Because SVG cannot erase what has already been drawn,
+// ClearRect only removes previous elements when it covers the whole canvas.
+type SVG struct {
+	w, h  float64
+	state styleStateMachine
+
+	path       strings.Builder
+	hasCurrent bool
+
+	body bytes.Buffer
+}
+
+var _ Canvas = (*SVG)(nil)
+
+// NewSVG creates an empty SVG canvas of the given size.
+func NewSVG(w, h float64) *SVG {
+	return &SVG{
+		w: w,
+		h: h,
+	}
+}
+
+func (s *SVG) Push(attr Attribute, value interface{}) {
+	s.state.push(attr, value)
+}
+
+func (s *SVG) Pop(attr Attribute) {
+	s.state.pop(attr)
+}
+
+func (s *SVG) value(attr Attribute) interface{} {
+	if v := s.state[attr]; v != nil {
+		return v.value
+	}
+	return nil
+}
+
+func (s *SVG) float(attr Attribute, fallback float64) float64 {
+	switch v := s.value(attr).(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	case int:
+		return float64(v)
+	}
+	return fallback
+}
+
+func formatSVGNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+func svgColor(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "black"
+	case string:
+		return v
+	case color.Color:
+		c := color.NRGBAModel.Convert(v).(color.NRGBA)
+		return fmt.Sprintf("rgba(%d, %d, %d, %s)", c.R, c.G, c.B, formatSVGNumber(float64(c.A)/255))
+	}
+	return fmt.Sprint(v)
+}
+
+func (s *SVG) attr(name, value string) {
+	s.body.WriteByte(' ')
+	s.body.WriteString(name)
+	s.body.WriteString(`="`)
+	xml.EscapeText(&s.body, []byte(value))
+	s.body.WriteByte('"')
+}
+
+func (s *SVG) opacity() {
+	if alpha := s.float(GlobalAlpha, 1); alpha < 1 {
+		s.attr("opacity", formatSVGNumber(alpha))
+	}
+}
+
+func (s *SVG) ClearRect(x, y, w, h float64) {
+	if x <= 0 && y <= 0 && x+w >= s.w && y+h >= s.h {
+		s.body.Reset()
+	}
+}
+
+func (s *SVG) BeginPath() {
+	s.path.Reset()
+	s.hasCurrent = false
+}
+
+func (s *SVG) ClosePath() {
+	if s.hasCurrent {
+		s.path.WriteString("Z")
+	}
+}
+
+func (s *SVG) MoveTo(x, y float64) {
+	fmt.Fprintf(&s.path, "M%s %s", formatSVGNumber(x), formatSVGNumber(y))
+	s.hasCurrent = true
+}
+
+func (s *SVG) LineTo(x, y float64) {
+	if !s.hasCurrent {
+		s.MoveTo(x, y)
+		return
+	}
+	fmt.Fprintf(&s.path, "L%s %s", formatSVGNumber(x), formatSVGNumber(y))
+}
+
+func (s *SVG) Rect(x, y, w, h float64) {
+	s.MoveTo(x, y)
+	s.LineTo(x+w, y)
+	s.LineTo(x+w, y+h)
+	s.LineTo(x, y+h)
+	s.ClosePath()
+}
+
+func (s *SVG) Arc(x, y, radius, startAngle, endAngle float64) {
+	sweep := endAngle - startAngle
+	if sweep > 2*math.Pi {
+		sweep = 2 * math.Pi
+	}
+
+	s.LineTo(x+radius*math.Cos(startAngle), y+radius*math.Sin(startAngle))
+
+	// An SVG arc cannot describe a full circle, so split the sweep into arcs of at most half a turn
+	n := int(math.Ceil(math.Abs(sweep) / math.Pi))
+	for i := 1; i <= n; i++ {
+		a := startAngle + sweep*float64(i)/float64(n)
+		flag := 1
+		if sweep < 0 {
+			flag = 0
+		}
+		fmt.Fprintf(&s.path, "A%s %s 0 0 %d %s %s",
+			formatSVGNumber(radius), formatSVGNumber(radius), flag,
+			formatSVGNumber(x+radius*math.Cos(a)), formatSVGNumber(y+radius*math.Sin(a)))
+	}
+}
+
+func (s *SVG) Fill() {
+	if s.path.Len() == 0 {
+		return
+	}
+
+	s.body.WriteString("<path")
+	s.attr("d", s.path.String())
+	s.attr("fill", svgColor(s.value(FillStyle)))
+	s.opacity()
+	s.body.WriteString("/>\n")
+}
+
+func (s *SVG) Stroke() {
+	if s.path.Len() == 0 {
+		return
+	}
+
+	s.body.WriteString("<path")
+	s.attr("d", s.path.String())
+	s.attr("fill", "none")
+	s.attr("stroke", svgColor(s.value(StrokeStyle)))
+	s.attr("stroke-width", formatSVGNumber(s.float(LineWidth, 1)))
+	s.opacity()
+	s.body.WriteString("/>\n")
+}
+
+func (s *SVG) font() string {
+	if font, ok := s.value(Font).(string); ok {
+		return font
+	}
+	return fmt.Sprintf("%dpx sans-serif", defaultFontSize)
+}
+
+// fontSize finds the size in pixels of the font attribute.
+func (s *SVG) fontSize() float64 {
+	for _, field := range strings.Fields(s.font()) {
+		if strings.HasSuffix(field, "px") {
+			if v, err := strconv.ParseFloat(strings.TrimSuffix(field, "px"), 64); err == nil {
+				return v
+			}
+		}
+	}
+	return defaultFontSize
+}
+
+var svgBaselines = map[string]string{
+	"top":         "text-before-edge",
+	"hanging":     "hanging",
+	"middle":      "middle",
+	"bottom":      "text-after-edge",
+	"ideographic": "ideographic",
+}
+
+func (s *SVG) FillText(text string, x, y float64) {
+	s.body.WriteString("<text")
+	s.attr("x", formatSVGNumber(x))
+	s.attr("y", formatSVGNumber(y))
+	s.attr("fill", svgColor(s.value(FillStyle)))
+	s.attr("style", "font: "+s.font())
+	if baseline, ok := s.value(TextBaseline).(string); ok && svgBaselines[baseline] != "" {
+		s.attr("dominant-baseline", svgBaselines[baseline])
+	}
+	s.opacity()
+	s.body.WriteString(">")
+	xml.EscapeText(&s.body, []byte(text))
+	s.body.WriteString("</text>\n")
+}
+
+// MeasureTextWidth estimates the width of text, as SVG text is only measured when it is displayed.
+func (s *SVG) MeasureTextWidth(text string) float64 {
+	return 0.6 * s.fontSize() * float64(len([]rune(text)))
+}
+
+func (s *SVG) MeasureFontHeight() float64 {
+	return s.fontSize()
+}
+
+// WriteTo writes the SVG document to w.
+func (s *SVG) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %[1]s %[2]s">`+"\n",
+		formatSVGNumber(s.w), formatSVGNumber(s.h))
+	buf.Write(s.body.Bytes())
+	buf.WriteString("</svg>\n")
+
+	return buf.WriteTo(w)
+}
@@ -0,0 +1,87 @@
+package draw
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func assertSVG(t *testing.T, s *SVG, want string) {
+	t.Helper()
+
+	var b strings.Builder
+	if _, err := s.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := b.String(); got != want {
+		t.Fatalf("expected SVG\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestSVGArc(t *testing.T) {
+	s := NewSVG(100, 50)
+	s.Push(FillStyle, "#ff0000")
+	s.BeginPath()
+	s.Arc(50, 25, 10, 0, 2*math.Pi)
+	s.Fill()
+
+	assertSVG(t, s, `<svg xmlns="http://www.w3.org/2000/svg" width="100" height="50" viewBox="0 0 100 50">
+<path d="M60 25A10 10 0 0 1 40 25A10 10 0 0 1 60 25" fill="#ff0000"/>
+</svg>
+`)
+}
+
+func TestSVGPath(t *testing.T) {
+	s := NewSVG(100, 50)
+	s.Push(StrokeStyle, "blue")
+	s.Push(LineWidth, 2.5)
+	s.Push(GlobalAlpha, 0.5)
+	s.BeginPath()
+	s.MoveTo(1, 2)
+	s.LineTo(10.125, 20)
+	s.LineTo(30, 40)
+	s.ClosePath()
+	s.Stroke()
+
+	assertSVG(t, s, `<svg xmlns="http://www.w3.org/2000/svg" width="100" height="50" viewBox="0 0 100 50">
+<path d="M1 2L10.13 20L30 40Z" fill="none" stroke="blue" stroke-width="2.5" opacity="0.5"/>
+</svg>
+`)
+}
+
+func TestSVGText(t *testing.T) {
+	s := NewSVG(100, 50)
+	s.Push(FillStyle, "white")
+	s.Push(Font, "12px monospace")
+	s.Push(TextBaseline, "middle")
+	s.FillText("Sun & <Moon>", 5, 10)
+
+	assertSVG(t, s, `<svg xmlns="http://www.w3.org/2000/svg" width="100" height="50" viewBox="0 0 100 50">
+<text x="5" y="10" fill="white" style="font: 12px monospace" dominant-baseline="middle">Sun &amp; &lt;Moon&gt;</text>
+</svg>
+`)
+
+	if h := s.MeasureFontHeight(); h != 12 {
+		t.Fatalf("expected a font height of 12, got %g", h)
+	}
+}
+
+func TestSVGClearRect(t *testing.T) {
+	s := NewSVG(100, 50)
+	s.BeginPath()
+	s.Rect(0, 0, 10, 10)
+	s.Fill()
+
+	// Clearing part of the canvas cannot erase an element
+	s.ClearRect(0, 0, 10, 10)
+	assertSVG(t, s, `<svg xmlns="http://www.w3.org/2000/svg" width="100" height="50" viewBox="0 0 100 50">
+<path d="M0 0L10 0L10 10L0 10Z" fill="black"/>
+</svg>
+`)
+
+	s.ClearRect(0, 0, 100, 50)
+	assertSVG(t, s, `<svg xmlns="http://www.w3.org/2000/svg" width="100" height="50" viewBox="0 0 100 50">
+</svg>
+`)
+}
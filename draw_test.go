@@ -0,0 +1,129 @@
+package universe
+
+import (
+	"github.com/relvacode/universe/draw"
+	"github.com/relvacode/universe/internal"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func assertCalls(t *testing.T, rec *draw.Recorder, expected []draw.Call) {
+	t.Helper()
+
+	if !reflect.DeepEqual(rec.Calls(), expected) {
+		var want strings.Builder
+		for _, c := range expected {
+			want.WriteString(c.String())
+			want.WriteByte('\n')
+		}
+		t.Fatalf("expected calls\n%s\nrecorded calls\n%s", want.String(), rec)
+	}
+}
+
+func TestEntityDraw(t *testing.T) {
+	e := NewEntity(internal.Vector{X: 10, Y: 20}, internal.Vector{}, 2)
+	c := draw.Camera{Zoom: 2, Offset: internal.Vector{X: 5, Y: 5}}
+
+	var rec draw.Recorder
+	e.Draw(&rec, c)
+
+	assertCalls(t, &rec, []draw.Call{
+		{Method: "BeginPath"},
+		{Method: "Arc", Args: []interface{}{10.0, 30.0, 4.0, 0.0, 2 * math.Pi}},
+		{Method: "Fill"},
+		{Method: "ClosePath"},
+	})
+}
+
+func TestEntityDrawMetadata(t *testing.T) {
+	e := NewEntity(internal.Vector{X: 10, Y: 20}, internal.Vector{}, 2)
+	e.Metadata = &Metadata{Name: "Sun", Color: "#ff0000"}
+	c := draw.Camera{Zoom: 2, Offset: internal.Vector{X: 5, Y: 5}}
+
+	var rec draw.Recorder
+	e.Draw(&rec, c)
+
+	assertCalls(t, &rec, []draw.Call{
+		{Method: "Push", Args: []interface{}{draw.FillStyle, "#ff0000"}},
+		{Method: "BeginPath"},
+		{Method: "Arc", Args: []interface{}{10.0, 30.0, 4.0, 0.0, 2 * math.Pi}},
+		{Method: "Fill"},
+		{Method: "ClosePath"},
+		{Method: "Push", Args: []interface{}{draw.TextBaseline, "middle"}},
+		{Method: "FillText", Args: []interface{}{"Sun", 10.0 + 4 + labelMargin, 30.0}},
+		{Method: "Pop", Args: []interface{}{draw.TextBaseline}},
+		{Method: "Pop", Args: []interface{}{draw.FillStyle}},
+	})
+
+	if v := rec.Style(draw.FillStyle); v != nil {
+		t.Fatalf("fill style %v was not restored", v)
+	}
+}
+
+func TestQuadTreeDraw(t *testing.T) {
+	qt := NewQuadTree(internal.BoundingBox{W: 100, H: 100}, 1, 4)
+	e := NewEntity(internal.Vector{X: 25, Y: 25}, internal.Vector{}, 2)
+	qt.Insert(e)
+
+	var rec draw.Recorder
+	qt.Draw(&rec)
+
+	assertCalls(t, &rec, []draw.Call{
+		{Method: "BeginPath"},
+		{Method: "MoveTo", Args: []interface{}{0.0, 0.0}},
+		{Method: "LineTo", Args: []interface{}{100.0, 0.0}},
+		{Method: "LineTo", Args: []interface{}{100.0, 100.0}},
+		{Method: "LineTo", Args: []interface{}{0.0, 100.0}},
+		{Method: "LineTo", Args: []interface{}{0.0, 0.0}},
+		{Method: "Stroke"},
+		{Method: "BeginPath"},
+		{Method: "Arc", Args: []interface{}{25.0, 25.0, math.Cbrt(e.M), 0.0, math.Pi * 2}},
+		{Method: "Stroke"},
+	})
+}
+
+func TestQuadTreeDrawSubdivided(t *testing.T) {
+	qt := NewQuadTree(internal.BoundingBox{W: 100, H: 100}, 1, 4)
+	qt.Insert(NewEntity(internal.Vector{X: 25, Y: 25}, internal.Vector{}, 1))
+	qt.Insert(NewEntity(internal.Vector{X: 75, Y: 25}, internal.Vector{}, 1))
+	qt.Insert(NewEntity(internal.Vector{X: 75, Y: 75}, internal.Vector{}, 1))
+
+	var rec draw.Recorder
+	qt.Draw(&rec)
+
+	// Only nodes containing entities are drawn, each with its boundary and centre of mass
+	nodes := qt.Nodes()
+	if nodes != 3 {
+		t.Fatalf("expected 3 nodes, got %d", nodes)
+	}
+	if n := rec.Count("Arc"); n != nodes {
+		t.Fatalf("expected %d arcs, got %d", nodes, n)
+	}
+	if n := rec.Count("Stroke"); n != 2*nodes {
+		t.Fatalf("expected %d strokes, got %d", 2*nodes, n)
+	}
+}
+
+func TestDrawEntities(t *testing.T) {
+	world := internal.BoundingBox{W: 100, H: 100}
+	c := draw.Camera{Zoom: 2}
+	c.FitCenter(world)
+
+	entities := EntityList{
+		NewEntity(internal.Vector{X: 50, Y: 50}, internal.Vector{}, 1),
+		// Outside of the camera, which shows the centre 50x50 of the world
+		NewEntity(internal.Vector{X: 5, Y: 5}, internal.Vector{}, 1),
+	}
+
+	var rec draw.Recorder
+	DrawEntities(&rec, entities, c, world)
+
+	assertCalls(t, &rec, []draw.Call{
+		{Method: "BeginPath"},
+		{Method: "Arc", Args: []interface{}{50.0, 50.0, 2.0, 0.0, 2 * math.Pi}},
+		{Method: "Fill"},
+		{Method: "ClosePath"},
+	})
+}
@@ -1,7 +1,6 @@
 package universe
 
 import (
-	"github.com/relvacode/universe/draw"
 	"github.com/relvacode/universe/internal"
 	"github.com/relvacode/universe/physics"
 	"math"
@@ -42,14 +41,23 @@ func NewEntity(p internal.Vector, v internal.Vector, r float64) *Entity {
 	}
 }
 
+// EntityID uniquely identifies an entity within a simulation.
+// IDs are never reused by a simulation and the zero ID is never given to an entity.
+type EntityID uint64
+
 type Entity struct {
 	physics.Object
+	// ID is given to the entity when it is added to a simulation
+	ID       EntityID
 	Disabled bool
-}
-
-func (e *Entity) Draw(ctx *draw.Context, c draw.Camera) {
-	ctx.BeginPath()
-	ctx.Arc((e.P.X-c.Offset.X)*c.Zoom, (e.P.Y-c.Offset.Y)*c.Zoom, e.R*c.Zoom, 0, 2*math.Pi)
-	ctx.Fill()
-	ctx.ClosePath()
+	Kind     BodyKind
+	// Path is the scripted path of a kinematic entity, it is not saved in snapshots
+	Path KinematicPath
+	// Metadata is an optional description of the entity
+	Metadata *Metadata
+
+	// level is the number of times the step is halved to find this entity's block timestep
+	level int
+	// outside is set if the centre of the entity was outside of the world boundary after the last step
+	outside bool
 }
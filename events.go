@@ -0,0 +1,163 @@
+package universe
+
+import (
+	"github.com/relvacode/universe/internal"
+)
+
+//go:generate go run github.com/abice/go-enum -f=$GOFILE --marshal
+
+// EventCause is the reason an entity was created or removed.
+//
+// EventCauseUser is an entity added or removed through the simulation's API, including a replay.
+//
+// EventCauseCollision is an entity created or removed by a collision resolver.
+//
+// EventCauseBoundary is an entity removed for leaving the world boundary.
+/*
+ENUM(
+user,
+collision,
+boundary
+)
+*/
+type EventCause uint8
+
+// Event is an event emitted by a simulation to its subscribers.
+// Each event is one of CollisionEvent, MergeEvent, CreatedEvent, RemovedEvent or BoundaryExitEvent.
+type Event interface {
+	event()
+}
+
+// CollisionEvent is emitted when two entities collide, before the collision is resolved.
+type CollisionEvent struct {
+	Step     int
+	E1, E2   *Entity
+	Distance float64
+	// RelativeVelocity is the velocity of E2 relative to E1 at the moment of impact.
+	RelativeVelocity internal.Vector
+}
+
+// MergeEvent is emitted when a collision resolver merges one entity into another.
+type MergeEvent struct {
+	Step   int
+	Into   *Entity
+	Merged *Entity
+}
+
+// CreatedEvent is emitted when an entity is added to the simulation.
+type CreatedEvent struct {
+	Step   int
+	Entity *Entity
+	Cause  EventCause
+}
+
+// RemovedEvent is emitted when an entity is disabled or removed from the simulation.
+type RemovedEvent struct {
+	Step   int
+	Entity *Entity
+	Cause  EventCause
+}
+
+// BoundaryExitEvent is emitted when the centre of an entity leaves the world boundary.
+// Mode is the boundary mode that was applied to the entity as it left,
+// entities that are reflected or wrapped around are moved back inside the world boundary.
+type BoundaryExitEvent struct {
+	Step   int
+	Entity *Entity
+	Mode   BoundaryMode
+}
+
+func (CollisionEvent) event()    {}
+func (MergeEvent) event()        {}
+func (CreatedEvent) event()      {}
+func (RemovedEvent) event()      {}
+func (BoundaryExitEvent) event() {}
+
+type subscriber struct {
+	id int
+	f  func(Event)
+}
+
+// Subscribe calls f with every event emitted by the simulation until the returned function is called.
+// Subscribers are called synchronously in the order they subscribed, while the simulation is stepping,
+// and must not add or remove entities or modify the simulation.
+func (s *Simulation) Subscribe(f func(Event)) (unsubscribe func()) {
+	s.nextSubscriber++
+	id := s.nextSubscriber
+	s.subscribers = append(s.subscribers, subscriber{id: id, f: f})
+
+	return func() {
+		for i, sub := range s.subscribers {
+			if sub.id == id {
+				s.subscribers = append(s.subscribers[:i:i], s.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// emitting checks if there are any subscribers,
+// so that events are not created when nothing is listening.
+func (s *Simulation) emitting() bool {
+	return len(s.subscribers) > 0
+}
+
+func (s *Simulation) emit(event Event) {
+	for _, sub := range s.subscribers {
+		sub.f(event)
+	}
+}
+
+// resolve resolves a collision between two entities using the resolver,
+// emitting an event for the collision and each of its outcomes.
+func (s *Simulation) resolve(resolver CollisionResolver, e1, e2 *Entity, distance float64) []*Entity {
+	// Entities that cannot be moved by collisions pass through each other
+	if !e1.Dynamic() && !e2.Dynamic() {
+		return nil
+	}
+
+	s.stats.ResolvedCollisions++
+
+	if !s.emitting() {
+		created := resolver.Resolve(e1, e2, distance)
+		for _, e := range created {
+			s.register(e)
+		}
+		return created
+	}
+
+	s.emit(CollisionEvent{
+		Step:     s.steps,
+		E1:       e1,
+		E2:       e2,
+		Distance: distance,
+		RelativeVelocity: internal.Vector{
+			X: e2.V.X - e1.V.X,
+			Y: e2.V.Y - e1.V.Y,
+		},
+	})
+
+	created := resolver.Resolve(e1, e2, distance)
+	for _, e := range created {
+		s.register(e)
+	}
+
+	switch {
+	case e1.Disabled && !e2.Disabled:
+		s.emit(MergeEvent{Step: s.steps, Into: e2, Merged: e1})
+	case e2.Disabled && !e1.Disabled:
+		s.emit(MergeEvent{Step: s.steps, Into: e1, Merged: e2})
+	}
+
+	for _, e := range created {
+		s.emit(CreatedEvent{Step: s.steps, Entity: e, Cause: EventCauseCollision})
+	}
+
+	for _, e := range [2]*Entity{e1, e2} {
+		if e.Disabled {
+			s.emit(RemovedEvent{Step: s.steps, Entity: e, Cause: EventCauseCollision})
+		}
+	}
+
+	return created
+}
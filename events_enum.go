@@ -0,0 +1,63 @@
+// Code generated by go-enum
+// DO NOT EDIT!
+
+package universe
+
+import (
+	"fmt"
+)
+
+const (
+	// EventCauseUser is a EventCause of type User
+	EventCauseUser EventCause = iota
+	// EventCauseCollision is a EventCause of type Collision
+	EventCauseCollision
+	// EventCauseBoundary is a EventCause of type Boundary
+	EventCauseBoundary
+)
+
+const _EventCauseName = "usercollisionboundary"
+
+var _EventCauseMap = map[EventCause]string{
+	0: _EventCauseName[0:4],
+	1: _EventCauseName[4:13],
+	2: _EventCauseName[13:21],
+}
+
+// String implements the Stringer interface.
+func (x EventCause) String() string {
+	if str, ok := _EventCauseMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("EventCause(%d)", x)
+}
+
+var _EventCauseValue = map[string]EventCause{
+	_EventCauseName[0:4]:   0,
+	_EventCauseName[4:13]:  1,
+	_EventCauseName[13:21]: 2,
+}
+
+// ParseEventCause attempts to convert a string to a EventCause
+func ParseEventCause(name string) (EventCause, error) {
+	if x, ok := _EventCauseValue[name]; ok {
+		return x, nil
+	}
+	return EventCause(0), fmt.Errorf("%s is not a valid EventCause", name)
+}
+
+// MarshalText implements the text marshaller method
+func (x EventCause) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method
+func (x *EventCause) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseEventCause(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
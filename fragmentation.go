@@ -0,0 +1,127 @@
+package universe
+
+import (
+	"github.com/relvacode/universe/internal"
+	"math"
+)
+
+const (
+	// DefaultFragmentationThreshold is the default specific impact energy required to fragment colliding entities.
+	DefaultFragmentationThreshold = 1000
+	// DefaultFragments is the default number of fragments created by a fragmenting collision.
+	DefaultFragments = 8
+	// DefaultMinimumFragmentMass is the default mass below which fragments are not created.
+	DefaultMinimumFragmentMass = 1
+)
+
+// FragmentationCollisionResolver shatters colliding entities into debris when the impact is energetic enough,
+// otherwise the entities are merged by Merge.
+//
+// The impact energy is the kinetic energy of the entities relative to their centre of mass.
+// They fragment when the impact energy per unit of combined mass exceeds Threshold,
+// which is also the binding energy per unit mass that is lost when they break apart.
+//
+// Debris is spread evenly on a ring around the centre of mass, moving outwards with the remaining energy.
+// Mass, momentum and the centre of mass are conserved.
+// Each fragment is given the metadata of the larger entity combined with the smaller by the metadata policy of Merge,
+// without a name.
+// The number of fragments is reduced so that no fragment is lighter than MinimumMass,
+// and the entities merge instead if fewer than two fragments would remain.
+// Entities that are not dynamic never fragment and are merged instead.
+type FragmentationCollisionResolver struct {
+	Threshold   float64 `json:"threshold"`
+	Fragments   int     `json:"fragments"`
+	MinimumMass float64 `json:"minimum_mass"`
+
+	Merge AbsorbCollisionResolver `json:"merge"`
+}
+
+// fragmentSpacing is the gap left between neighbouring fragments as a fraction of their radius.
+const fragmentSpacing = 0.05
+
+// fragments calculates the number of fragments the total mass can be split into.
+func (r FragmentationCollisionResolver) fragments(mass float64) int {
+	n := r.Fragments
+	if r.MinimumMass > 0 {
+		if limit := int(mass / r.MinimumMass); limit < n {
+			n = limit
+		}
+	}
+	return n
+}
+
+func (r FragmentationCollisionResolver) Resolve(e1, e2 *Entity, distance float64) []*Entity {
+	mass := e1.M + e2.M
+
+	relative := internal.Vector{
+		X: e2.V.X - e1.V.X,
+		Y: e2.V.Y - e1.V.Y,
+	}
+
+	// Kinetic energy in the centre of mass frame using the reduced mass
+	impact := 0.5 * (e1.M * e2.M / mass) * relative.Dot()
+	binding := r.Threshold * mass
+
+	n := r.fragments(mass)
+	if impact < binding || n < 2 || !e1.Dynamic() || !e2.Dynamic() {
+		return r.Merge.Resolve(e1, e2, distance)
+	}
+
+	center := internal.Vector{
+		X: (e1.M*e1.P.X + e2.M*e2.P.X) / mass,
+		Y: (e1.M*e1.P.Y + e2.M*e2.P.Y) / mass,
+	}
+	velocity := internal.Vector{
+		X: (e1.M*e1.V.X + e2.M*e2.V.X) / mass,
+		Y: (e1.M*e1.V.Y + e2.M*e2.V.Y) / mass,
+	}
+
+	m := mass / float64(n)
+	radius := math.Cbrt(m)
+
+	// Place fragments far enough apart on the ring that they do not immediately collide with each other
+	ring := radius * (1 + fragmentSpacing) / math.Sin(math.Pi/float64(n))
+
+	// The remaining energy is shared equally so every fragment moves outwards at the same speed
+	speed := math.Sqrt(2 * (impact - binding) / mass)
+
+	// Align the debris with the direction of impact so that fragmentation is deterministic
+	start := relative.Direction()
+
+	e1.Disabled = true
+	e2.Disabled = true
+
+	larger, smaller := e1, e2
+	if e2.M > e1.M {
+		larger, smaller = e2, e1
+	}
+
+	metadata := r.Merge.Metadata.Merge(larger.Metadata, smaller.Metadata).Clone()
+	if metadata != nil {
+		metadata.Name = ""
+	}
+
+	debris := make([]*Entity, n)
+	for i := range debris {
+		angle := start + 2*math.Pi*float64(i)/float64(n)
+		cos, sin := math.Cos(angle), math.Sin(angle)
+
+		fragment := NewEntity(
+			internal.Vector{
+				X: center.X + ring*cos,
+				Y: center.Y + ring*sin,
+			},
+			internal.Vector{
+				X: velocity.X + speed*cos,
+				Y: velocity.Y + speed*sin,
+			},
+			radius,
+		)
+		fragment.M = m
+		fragment.Metadata = metadata.Clone()
+
+		debris[i] = fragment
+	}
+
+	return debris
+}
@@ -4,5 +4,7 @@ go 1.15
 
 require (
 	github.com/abice/go-enum v0.2.5 // indirect
+	golang.org/x/image v0.0.0-20201208152932-35266b937fa6
 	golang.org/x/tools v0.0.0-20201114224030-61ea331ec02b
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,118 @@
+package universe
+
+import (
+	"github.com/relvacode/universe/physics"
+)
+
+// register gives an entity a unique ID and adds it to the index.
+// An entity keeps its existing ID unless it is zero or used by another entity in the simulation.
+func (s *Simulation) register(e *Entity) {
+	if s.index == nil {
+		s.index = make(map[EntityID]*Entity)
+	}
+
+	if o, ok := s.index[e.ID]; e.ID == 0 || ok && o != e {
+		s.nextID++
+		e.ID = s.nextID
+	}
+
+	if e.ID > s.nextID {
+		s.nextID = e.ID
+	}
+
+	s.index[e.ID] = e
+}
+
+// registerAll registers every entity that has been added to the entity list without an ID.
+func (s *Simulation) registerAll() {
+	for _, e := range s.entities {
+		if o, ok := s.index[e.ID]; !ok || o != e {
+			s.register(e)
+		}
+	}
+}
+
+// deleteDisabled removes every disabled entity from the simulation and the index.
+func (s *Simulation) deleteDisabled() int {
+	if s.particlesCurrent {
+		s.particles.DeleteSweep(func(i int) bool {
+			return s.entities[i].Disabled
+		})
+	}
+
+	return s.entities.DeleteSweep(func(e *Entity) bool {
+		if !e.Disabled {
+			return false
+		}
+
+		if s.index[e.ID] == e {
+			delete(s.index, e.ID)
+		}
+		return true
+	})
+}
+
+// EntityByID returns the entity in the simulation with the given ID.
+// The entity may be modified directly, so the entities become the state of the simulation until the next step.
+func (s *Simulation) EntityByID(id EntityID) (*Entity, bool) {
+	s.releaseParticles()
+
+	e, ok := s.index[id]
+	return e, ok
+}
+
+// UpdateByID replaces the position, velocity, radius and mass of the entity with the given ID.
+// Returns false if there is no entity with the ID.
+func (s *Simulation) UpdateByID(id EntityID, o physics.Object) bool {
+	e, ok := s.index[id]
+	if !ok {
+		return false
+	}
+
+	s.syncEntities()
+
+	update := snapshotEntity(e)
+	update.P, update.V, update.R, update.M = o.P, o.V, o.R, o.M
+	s.record(ReplayEvent{
+		Kind:   ReplayEventKindUpdate,
+		Entity: &update,
+	})
+
+	e.P, e.V, e.R, e.M = o.P, o.V, o.R, o.M
+	s.loadParticle(e)
+	return true
+}
+
+// SetMetadataByID replaces the metadata of the entity with the given ID.
+// Returns false if there is no entity with the ID.
+func (s *Simulation) SetMetadataByID(id EntityID, metadata *Metadata) bool {
+	e, ok := s.index[id]
+	if !ok {
+		return false
+	}
+
+	s.syncEntities()
+
+	update := snapshotEntity(e)
+	update.Metadata = metadata.Clone()
+	s.record(ReplayEvent{
+		Kind:   ReplayEventKindUpdate,
+		Entity: &update,
+	})
+
+	e.Metadata = metadata
+	return true
+}
+
+// RemoveByID removes every entity with one of the given IDs.
+// Returns the number of entities removed.
+func (s *Simulation) RemoveByID(ids ...EntityID) int {
+	remove := make(map[EntityID]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	return s.Remove(func(e *Entity) bool {
+		return remove[e.ID]
+	})
+}
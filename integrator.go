@@ -0,0 +1,271 @@
+package universe
+
+import (
+	"github.com/relvacode/universe/internal"
+)
+
+// Integrator advances the position and velocity of every entity over a timestep.
+// Calling accelerate updates the acceleration of every entity from their current positions.
+type Integrator interface {
+	Integrate(entities EntityList, timestep float64, accelerate func())
+}
+
+// particleIntegrator is implemented by integrators that can advance the particle store of a simulation directly.
+// Calling accelerate updates the acceleration of every particle from their current positions.
+type particleIntegrator interface {
+	IntegrateParticles(ps *ParticleStore, timestep float64, accelerate func())
+}
+
+// IntegratorByName returns a new instance of the built-in integrator with the given name.
+func IntegratorByName(name string) (Integrator, bool) {
+	switch name {
+	case "euler":
+		return EulerIntegrator{}, true
+	case "leapfrog":
+		return LeapfrogIntegrator{}, true
+	case "verlet":
+		return new(VerletIntegrator), true
+	case "rk4":
+		return new(RK4Integrator), true
+	}
+
+	return nil, false
+}
+
+// IntegratorName returns the name of a built-in integrator.
+func IntegratorName(integrator Integrator) (string, bool) {
+	switch integrator.(type) {
+	case EulerIntegrator, *EulerIntegrator:
+		return "euler", true
+	case LeapfrogIntegrator, *LeapfrogIntegrator:
+		return "leapfrog", true
+	case *VerletIntegrator:
+		return "verlet", true
+	case *RK4Integrator:
+		return "rk4", true
+	}
+
+	return "", false
+}
+
+var _ Integrator = EulerIntegrator{}
+
+// EulerIntegrator is a semi-implicit Euler integrator.
+// Velocities are kicked by the acceleration at the start of the step before positions are moved.
+// It is the cheapest integrator, requiring a single acceleration per step, but drifts energy over long orbits.
+type EulerIntegrator struct{}
+
+func (EulerIntegrator) Integrate(entities EntityList, timestep float64, accelerate func()) {
+	accelerate()
+
+	for _, e := range entities {
+		e.Kick(timestep)
+		e.Step(timestep)
+	}
+}
+
+func (EulerIntegrator) IntegrateParticles(ps *ParticleStore, timestep float64, accelerate func()) {
+	accelerate()
+
+	ps.kick(timestep)
+	ps.drift(timestep)
+}
+
+var _ Integrator = LeapfrogIntegrator{}
+
+// LeapfrogIntegrator is a symplectic kick-drift-kick leapfrog integrator.
+type LeapfrogIntegrator struct{}
+
+func (LeapfrogIntegrator) Integrate(entities EntityList, timestep float64, accelerate func()) {
+	half := timestep / 2
+
+	accelerate()
+	for _, e := range entities {
+		e.Kick(half)
+		e.Step(timestep)
+	}
+
+	accelerate()
+	for _, e := range entities {
+		e.Kick(half)
+	}
+}
+
+func (LeapfrogIntegrator) IntegrateParticles(ps *ParticleStore, timestep float64, accelerate func()) {
+	half := timestep / 2
+
+	accelerate()
+	ps.kick(half)
+	ps.drift(timestep)
+
+	accelerate()
+	ps.kick(half)
+}
+
+var _ Integrator = (*VerletIntegrator)(nil)
+
+// VerletIntegrator is a symplectic velocity Verlet integrator.
+type VerletIntegrator struct {
+	a []internal.Vector
+}
+
+func (vi *VerletIntegrator) Integrate(entities EntityList, timestep float64, accelerate func()) {
+	if cap(vi.a) < len(entities) {
+		vi.a = make([]internal.Vector, len(entities))
+	}
+	vi.a = vi.a[:len(entities)]
+
+	accelerate()
+	for i, e := range entities {
+		vi.a[i] = e.A
+		e.P.X += (e.V.X * timestep) + (0.5 * e.A.X * timestep * timestep)
+		e.P.Y += (e.V.Y * timestep) + (0.5 * e.A.Y * timestep * timestep)
+	}
+
+	accelerate()
+	for i, e := range entities {
+		e.V.X += 0.5 * (vi.a[i].X + e.A.X) * timestep
+		e.V.Y += 0.5 * (vi.a[i].Y + e.A.Y) * timestep
+	}
+}
+
+func (vi *VerletIntegrator) IntegrateParticles(ps *ParticleStore, timestep float64, accelerate func()) {
+	n := ps.Len()
+	if cap(vi.a) < n {
+		vi.a = make([]internal.Vector, n)
+	}
+	vi.a = vi.a[:n]
+
+	accelerate()
+	for i := 0; i < n; i++ {
+		vi.a[i] = internal.Vector{X: ps.AX[i], Y: ps.AY[i]}
+		ps.X[i] += (ps.VX[i] * timestep) + (0.5 * ps.AX[i] * timestep * timestep)
+		ps.Y[i] += (ps.VY[i] * timestep) + (0.5 * ps.AY[i] * timestep * timestep)
+	}
+
+	accelerate()
+	for i := 0; i < n; i++ {
+		ps.VX[i] += 0.5 * (vi.a[i].X + ps.AX[i]) * timestep
+		ps.VY[i] += 0.5 * (vi.a[i].Y + ps.AY[i]) * timestep
+	}
+}
+
+var _ Integrator = (*RK4Integrator)(nil)
+
+// RK4Integrator is a classical fourth order Runge-Kutta integrator.
+// It requires four accelerations per step and is not symplectic,
+// but is very accurate for short timesteps.
+type RK4Integrator struct {
+	p, v   []internal.Vector
+	dp, dv []internal.Vector
+}
+
+func (rk *RK4Integrator) grow(n int) {
+	if cap(rk.p) < n {
+		rk.p = make([]internal.Vector, n)
+		rk.v = make([]internal.Vector, n)
+		rk.dp = make([]internal.Vector, n)
+		rk.dv = make([]internal.Vector, n)
+	}
+
+	rk.p = rk.p[:n]
+	rk.v = rk.v[:n]
+	rk.dp = rk.dp[:n]
+	rk.dv = rk.dv[:n]
+}
+
+func (rk *RK4Integrator) Integrate(entities EntityList, timestep float64, accelerate func()) {
+	rk.grow(len(entities))
+
+	for i, e := range entities {
+		rk.p[i] = e.P
+		rk.v[i] = e.V
+		rk.dp[i] = internal.Vector{}
+		rk.dv[i] = internal.Vector{}
+	}
+
+	// Each stage evaluates the derivative at the current trial state,
+	// accumulates it with its weight and then moves each entity to the trial state of the next stage.
+	for _, stage := range rk4Stages(timestep) {
+		accelerate()
+
+		for i, e := range entities {
+			rk.dp[i].X += stage.weight * e.V.X
+			rk.dp[i].Y += stage.weight * e.V.Y
+			rk.dv[i].X += stage.weight * e.A.X
+			rk.dv[i].Y += stage.weight * e.A.Y
+
+			if stage.next == 0 {
+				continue
+			}
+
+			v := e.V
+			e.P.X = rk.p[i].X + v.X*stage.next
+			e.P.Y = rk.p[i].Y + v.Y*stage.next
+			e.V.X = rk.v[i].X + e.A.X*stage.next
+			e.V.Y = rk.v[i].Y + e.A.Y*stage.next
+		}
+	}
+
+	for i, e := range entities {
+		e.P.X = rk.p[i].X + rk.dp[i].X*timestep/6
+		e.P.Y = rk.p[i].Y + rk.dp[i].Y*timestep/6
+		e.V.X = rk.v[i].X + rk.dv[i].X*timestep/6
+		e.V.Y = rk.v[i].Y + rk.dv[i].Y*timestep/6
+	}
+}
+
+type rk4Stage struct {
+	weight float64
+	next   float64
+}
+
+// rk4Stages returns the weight of each stage of a step and the time after the start of the step of the next trial state.
+func rk4Stages(timestep float64) [4]rk4Stage {
+	return [4]rk4Stage{
+		{weight: 1, next: timestep / 2},
+		{weight: 2, next: timestep / 2},
+		{weight: 2, next: timestep},
+		{weight: 1},
+	}
+}
+
+func (rk *RK4Integrator) IntegrateParticles(ps *ParticleStore, timestep float64, accelerate func()) {
+	n := ps.Len()
+	rk.grow(n)
+
+	for i := 0; i < n; i++ {
+		rk.p[i] = internal.Vector{X: ps.X[i], Y: ps.Y[i]}
+		rk.v[i] = internal.Vector{X: ps.VX[i], Y: ps.VY[i]}
+		rk.dp[i] = internal.Vector{}
+		rk.dv[i] = internal.Vector{}
+	}
+
+	for _, stage := range rk4Stages(timestep) {
+		accelerate()
+
+		for i := 0; i < n; i++ {
+			rk.dp[i].X += stage.weight * ps.VX[i]
+			rk.dp[i].Y += stage.weight * ps.VY[i]
+			rk.dv[i].X += stage.weight * ps.AX[i]
+			rk.dv[i].Y += stage.weight * ps.AY[i]
+
+			if stage.next == 0 {
+				continue
+			}
+
+			vx, vy := ps.VX[i], ps.VY[i]
+			ps.X[i] = rk.p[i].X + vx*stage.next
+			ps.Y[i] = rk.p[i].Y + vy*stage.next
+			ps.VX[i] = rk.v[i].X + ps.AX[i]*stage.next
+			ps.VY[i] = rk.v[i].Y + ps.AY[i]*stage.next
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		ps.X[i] = rk.p[i].X + rk.dp[i].X*timestep/6
+		ps.Y[i] = rk.p[i].Y + rk.dp[i].Y*timestep/6
+		ps.VX[i] = rk.v[i].X + rk.dv[i].X*timestep/6
+		ps.VY[i] = rk.v[i].Y + rk.dv[i].Y*timestep/6
+	}
+}
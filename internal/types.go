@@ -60,9 +60,11 @@ func (bb BoundingBox) Center() Vector {
 	}
 }
 
+// ContainsPoint checks if the point is inside the box.
+// The top and left edges are inclusive so that adjacent boxes never both contain the same point.
 func (bb BoundingBox) ContainsPoint(v Vector) bool {
-	return bb.X < v.X && bb.X+bb.W > v.X &&
-		bb.Y < v.Y && bb.Y+bb.H > v.Y
+	return bb.X <= v.X && bb.X+bb.W > v.X &&
+		bb.Y <= v.Y && bb.Y+bb.H > v.Y
 }
 
 func (bb BoundingBox) Intersects(o BoundingBox) bool {
@@ -61,7 +61,7 @@ func main() {
 		X: 0, Y: 0, W: width, H: height,
 	}
 
-	vc := controller.New(box, universe.AbsorbCollisionResolver)
+	vc := controller.New(box, universe.AbsorbCollisionResolver{})
 	vc.Bind(document)
 
 	r := universe.NewRenderer(
@@ -0,0 +1,141 @@
+package universe
+
+import (
+	"sort"
+)
+
+//go:generate go run github.com/abice/go-enum -f=$GOFILE --marshal
+
+// Metadata describes an entity to people and applications.
+// It does not affect the physics of the entity.
+//
+// Color is any CSS colour understood by the canvas the entity is drawn on.
+// Tags are kept sorted and unique when modified with AddTag.
+type Metadata struct {
+	Name       string            `json:"name,omitempty"`
+	Color      string            `json:"color,omitempty"`
+	Tags       []string          `json:"tags,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// Clone returns a deep copy of the metadata.
+func (m *Metadata) Clone() *Metadata {
+	if m == nil {
+		return nil
+	}
+
+	clone := &Metadata{
+		Name:  m.Name,
+		Color: m.Color,
+	}
+
+	if m.Tags != nil {
+		clone.Tags = append([]string(nil), m.Tags...)
+	}
+
+	if m.Properties != nil {
+		clone.Properties = make(map[string]string, len(m.Properties))
+		for k, v := range m.Properties {
+			clone.Properties[k] = v
+		}
+	}
+
+	return clone
+}
+
+// HasTag reports whether the metadata has a tag.
+// Nil metadata has no tags.
+func (m *Metadata) HasTag(tag string) bool {
+	if m == nil {
+		return false
+	}
+
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTag adds a tag to the metadata if it does not already have it.
+// The metadata must not be nil, allocate it on the entity before adding tags.
+func (m *Metadata) AddTag(tag string) {
+	if m.HasTag(tag) {
+		return
+	}
+
+	m.Tags = append(m.Tags, tag)
+	sort.Strings(m.Tags)
+}
+
+// RemoveTag removes a tag from the metadata if it has it.
+// Removing a tag from nil metadata does nothing.
+func (m *Metadata) RemoveTag(tag string) {
+	if m == nil {
+		return
+	}
+
+	for i, t := range m.Tags {
+		if t == tag {
+			m.Tags = append(m.Tags[:i:i], m.Tags[i+1:]...)
+			return
+		}
+	}
+}
+
+// MetadataMerge is how the metadata of two entities is combined when one is merged into the other.
+//
+// MetadataMergeKeep keeps the metadata of the entity that survives the merge.
+//
+// MetadataMergeUnion keeps the name and colour of the surviving entity, or those of the merged entity if it has none.
+// Tags of both entities are combined and properties are combined with those of the surviving entity taking precedence.
+//
+// MetadataMergeDiscard removes the metadata of the surviving entity.
+/*
+ENUM(
+keep,
+union,
+discard
+)
+*/
+type MetadataMerge uint8
+
+// Merge returns the metadata of an entity that has absorbed another entity.
+// The result may be either of the given metadata, or a new combination of both.
+func (x MetadataMerge) Merge(survivor, merged *Metadata) *Metadata {
+	switch x {
+	case MetadataMergeDiscard:
+		return nil
+	case MetadataMergeUnion:
+		if merged == nil {
+			return survivor
+		}
+		if survivor == nil {
+			return merged.Clone()
+		}
+
+		union := survivor.Clone()
+		if union.Name == "" {
+			union.Name = merged.Name
+		}
+		if union.Color == "" {
+			union.Color = merged.Color
+		}
+		for _, tag := range merged.Tags {
+			union.AddTag(tag)
+		}
+		for k, v := range merged.Properties {
+			if _, ok := union.Properties[k]; ok {
+				continue
+			}
+			if union.Properties == nil {
+				union.Properties = make(map[string]string)
+			}
+			union.Properties[k] = v
+		}
+		return union
+	}
+
+	return survivor
+}
@@ -0,0 +1,63 @@
+// Code generated by go-enum
+// DO NOT EDIT!
+
+package universe
+
+import (
+	"fmt"
+)
+
+const (
+	// MetadataMergeKeep is a MetadataMerge of type Keep
+	MetadataMergeKeep MetadataMerge = iota
+	// MetadataMergeUnion is a MetadataMerge of type Union
+	MetadataMergeUnion
+	// MetadataMergeDiscard is a MetadataMerge of type Discard
+	MetadataMergeDiscard
+)
+
+const _MetadataMergeName = "keepuniondiscard"
+
+var _MetadataMergeMap = map[MetadataMerge]string{
+	0: _MetadataMergeName[0:4],
+	1: _MetadataMergeName[4:9],
+	2: _MetadataMergeName[9:16],
+}
+
+// String implements the Stringer interface.
+func (x MetadataMerge) String() string {
+	if str, ok := _MetadataMergeMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("MetadataMerge(%d)", x)
+}
+
+var _MetadataMergeValue = map[string]MetadataMerge{
+	_MetadataMergeName[0:4]:  0,
+	_MetadataMergeName[4:9]:  1,
+	_MetadataMergeName[9:16]: 2,
+}
+
+// ParseMetadataMerge attempts to convert a string to a MetadataMerge
+func ParseMetadataMerge(name string) (MetadataMerge, error) {
+	if x, ok := _MetadataMergeValue[name]; ok {
+		return x, nil
+	}
+	return MetadataMerge(0), fmt.Errorf("%s is not a valid MetadataMerge", name)
+}
+
+// MarshalText implements the text marshaller method
+func (x MetadataMerge) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method
+func (x *MetadataMerge) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseMetadataMerge(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
@@ -0,0 +1,53 @@
+// +build !js
+
+package universe
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelChunk is the number of items a worker takes at a time.
+const parallelChunk = 64
+
+// parallel calls f over consecutive ranges of [0, n) from a pool of worker goroutines.
+// Ranges are handed out dynamically so that workers finishing cheap ranges early take on more work.
+// If workers is zero then one worker is started for each available CPU.
+func parallel(workers, n int, f func(start, end int)) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if chunks := (n + parallelChunk - 1) / parallelChunk; workers > chunks {
+		workers = chunks
+	}
+	if workers <= 1 {
+		f(0, n)
+		return
+	}
+
+	var next int64
+	var wg sync.WaitGroup
+	wg.Add(workers)
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				start := int(atomic.AddInt64(&next, parallelChunk)) - parallelChunk
+				if start >= n {
+					return
+				}
+
+				end := start + parallelChunk
+				if end > n {
+					end = n
+				}
+
+				f(start, end)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
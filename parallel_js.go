@@ -0,0 +1,9 @@
+// +build js
+
+package universe
+
+// parallel calls f over the whole range of [0, n).
+// WebAssembly runs on a single thread so there is nothing to gain from starting workers.
+func parallel(_, n int, f func(start, end int)) {
+	f(0, n)
+}
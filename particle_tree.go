@@ -0,0 +1,222 @@
+package universe
+
+import (
+	"github.com/relvacode/universe/internal"
+	"github.com/relvacode/universe/physics"
+	"math"
+)
+
+type particleNode struct {
+	boundary internal.BoundingBox
+
+	centerOfMass internal.Vector
+	totalMass    float64
+
+	// start and end are the range of sorted particles contained in this node
+	start, end int
+	// children is the index of the first of four consecutive child nodes, or zero if this node is a leaf
+	children int
+}
+
+// ParticleTree is a quad tree built over a ParticleStore.
+// Particles are sorted into tree order so that every node covers a contiguous range of particles,
+// and the nodes themselves are kept in a single slice that is reused between builds.
+type ParticleTree struct {
+	nodes []particleNode
+
+	// order is the index in the store of each sorted particle
+	order   []int
+	x, y, m []float64
+}
+
+// Build sorts every live particle in the store into the tree.
+// Disabled particles and particles outside of the boundary are ignored.
+// Nodes are subdivided when they contain more than maxEntries particles until maxDepth is reached.
+func (pt *ParticleTree) Build(ps *ParticleStore, boundary internal.BoundingBox, maxEntries, maxDepth int) {
+	pt.order = pt.order[:0]
+
+	for i := 0; i < ps.Len(); i++ {
+		if !ps.Disabled[i] && boundary.ContainsPoint(internal.Vector{X: ps.X[i], Y: ps.Y[i]}) {
+			pt.order = append(pt.order, i)
+		}
+	}
+
+	pt.nodes = append(pt.nodes[:0], particleNode{
+		boundary: boundary,
+		end:      len(pt.order),
+	})
+
+	pt.subdivide(ps, 0, maxEntries, maxDepth)
+
+	pt.x = pt.x[:0]
+	pt.y = pt.y[:0]
+	pt.m = pt.m[:0]
+
+	for _, i := range pt.order {
+		pt.x = append(pt.x, ps.X[i])
+		pt.y = append(pt.y, ps.Y[i])
+		pt.m = append(pt.m, ps.M[i])
+	}
+}
+
+// partition moves each particle in the range for which less returns true to the start of the range.
+// Returns the index of the first particle for which less returns false.
+func (pt *ParticleTree) partition(start, end int, less func(i int) bool) int {
+	for k := start; k < end; k++ {
+		if less(pt.order[k]) {
+			pt.order[k], pt.order[start] = pt.order[start], pt.order[k]
+			start++
+		}
+	}
+	return start
+}
+
+func (pt *ParticleTree) subdivide(ps *ParticleStore, n, maxEntries, remainingDepth int) {
+	node := &pt.nodes[n]
+
+	var massVector internal.Vector
+	for _, i := range pt.order[node.start:node.end] {
+		massVector.X += ps.X[i] * ps.M[i]
+		massVector.Y += ps.Y[i] * ps.M[i]
+		node.totalMass += ps.M[i]
+	}
+
+	if node.totalMass > 0 {
+		node.centerOfMass = internal.Vector{
+			X: massVector.X / node.totalMass,
+			Y: massVector.Y / node.totalMass,
+		}
+	}
+
+	if node.end-node.start <= maxEntries || remainingDepth == 0 {
+		return
+	}
+
+	box := node.boundary
+	start, end := node.start, node.end
+	w := box.W / 2
+	h := box.H / 2
+
+	// Split the range into the top and bottom halves, then each half into its left and right quarters
+	middle := pt.partition(start, end, func(i int) bool { return ps.Y[i] < box.Y+h })
+	topMiddle := pt.partition(start, middle, func(i int) bool { return ps.X[i] < box.X+w })
+	bottomMiddle := pt.partition(middle, end, func(i int) bool { return ps.X[i] < box.X+w })
+
+	children := len(pt.nodes)
+	node.children = children
+
+	// node is invalid after the nodes slice grows
+	pt.nodes = append(pt.nodes,
+		particleNode{
+			boundary: internal.BoundingBox{X: box.X, Y: box.Y, W: w, H: h},
+			start:    start, end: topMiddle,
+		},
+		particleNode{
+			boundary: internal.BoundingBox{X: box.X + w, Y: box.Y, W: w, H: h},
+			start:    topMiddle, end: middle,
+		},
+		particleNode{
+			boundary: internal.BoundingBox{X: box.X, Y: box.Y + h, W: w, H: h},
+			start:    middle, end: bottomMiddle,
+		},
+		particleNode{
+			boundary: internal.BoundingBox{X: box.X + w, Y: box.Y + h, W: w, H: h},
+			start:    bottomMiddle, end: end,
+		},
+	)
+
+	for c := children; c < children+4; c++ {
+		pt.subdivide(ps, c, maxEntries, remainingDepth-1)
+	}
+}
+
+// Intersections calls f with the index in the store of every particle in each tree node that intersects the box.
+// If f returns false, then no more particles are evaluated.
+// Returns true if all possible particles were iterated over.
+func (pt *ParticleTree) Intersections(box internal.BoundingBox, f func(i int) bool) bool {
+	if len(pt.nodes) == 0 {
+		return true
+	}
+	return pt.intersections(0, box, f)
+}
+
+func (pt *ParticleTree) intersections(n int, box internal.BoundingBox, f func(i int) bool) bool {
+	node := &pt.nodes[n]
+	if !node.boundary.Intersects(box) {
+		return true
+	}
+
+	if node.children == 0 {
+		for _, i := range pt.order[node.start:node.end] {
+			if !f(i) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for c := node.children; c < node.children+4; c++ {
+		if !pt.intersections(c, box, f) {
+			return false
+		}
+	}
+	return true
+}
+
+// Acceleration calculates the gravitational acceleration of the particle at index i in the store
+// caused by every other particle using the same Barnes-Hut approximation as QuadTree.
+func (pt *ParticleTree) Acceleration(ps *ParticleStore, i int, theta float64, g physics.Gravity) internal.Vector {
+	return pt.AccelerationAt(ps, i, internal.Vector{X: ps.X[i], Y: ps.Y[i]}, theta, g)
+}
+
+// AccelerationAt calculates the gravitational acceleration at p caused by every particle other than the particle at index i.
+func (pt *ParticleTree) AccelerationAt(ps *ParticleStore, i int, p internal.Vector, theta float64, g physics.Gravity) internal.Vector {
+	var a internal.Vector
+	pt.accelerate(0, i, p, theta, g, &a)
+	return a
+}
+
+func (pt *ParticleTree) accelerate(n int, i int, p internal.Vector, theta float64, g physics.Gravity, a *internal.Vector) {
+	node := &pt.nodes[n]
+	if node.totalMass == 0 {
+		return
+	}
+
+	if node.children == 0 {
+		for k := node.start; k < node.end; k++ {
+			if pt.order[k] == i {
+				continue
+			}
+
+			f := g.Acceleration(p, internal.Vector{X: pt.x[k], Y: pt.y[k]}, pt.m[k])
+			a.X += f.X
+			a.Y += f.Y
+		}
+		return
+	}
+
+	if !node.opens(p, theta) {
+		f := g.Acceleration(p, node.centerOfMass, node.totalMass)
+		a.X += f.X
+		a.Y += f.Y
+		return
+	}
+
+	for c := node.children; c < node.children+4; c++ {
+		pt.accelerate(c, i, p, theta, g, a)
+	}
+}
+
+// opens checks if the node is too close to p to be treated as a single body.
+func (node *particleNode) opens(p internal.Vector, theta float64) bool {
+	if node.boundary.ContainsPoint(p) {
+		return true
+	}
+
+	delta := internal.Vector{
+		X: node.centerOfMass.X - p.X,
+		Y: node.centerOfMass.Y - p.Y,
+	}
+
+	return math.Max(node.boundary.W, node.boundary.H) >= theta*math.Sqrt(delta.Dot())
+}
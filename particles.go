@@ -0,0 +1,440 @@
+package universe
+
+import (
+	"github.com/relvacode/universe/internal"
+	"github.com/relvacode/universe/physics"
+	"math"
+	"time"
+)
+
+// NewParticleStore creates an empty particle store with space for capacity particles.
+func NewParticleStore(capacity int) *ParticleStore {
+	return &ParticleStore{
+		ID:       make([]EntityID, 0, capacity),
+		X:        make([]float64, 0, capacity),
+		Y:        make([]float64, 0, capacity),
+		VX:       make([]float64, 0, capacity),
+		VY:       make([]float64, 0, capacity),
+		AX:       make([]float64, 0, capacity),
+		AY:       make([]float64, 0, capacity),
+		R:        make([]float64, 0, capacity),
+		M:        make([]float64, 0, capacity),
+		Kind:     make([]BodyKind, 0, capacity),
+		Disabled: make([]bool, 0, capacity),
+		outside:  make([]bool, 0, capacity),
+		index:    make(map[EntityID]int, capacity),
+	}
+}
+
+// ParticleStore is the state of every entity in a simulation stored as a structure of arrays.
+// Integration, building the tree, gravity and collision detection iterate over contiguous arrays
+// instead of chasing entity pointers, which is much faster for large numbers of entities.
+//
+// All arrays have the same length and the particle at index i is the entity at index i in the simulation's entity list.
+// Each particle keeps the ID of its entity, which is stable for as long as the entity is in the simulation,
+// so a particle can be found with Index even though removing particles moves the particles after them.
+type ParticleStore struct {
+	ID       []EntityID
+	X, Y     []float64
+	VX, VY   []float64
+	AX, AY   []float64
+	R, M     []float64
+	Kind     []BodyKind
+	Disabled []bool
+
+	// outside is set if the centre of the particle was outside of the world boundary after the last step
+	outside []bool
+
+	index map[EntityID]int
+}
+
+func (ps *ParticleStore) Len() int {
+	return len(ps.ID)
+}
+
+// Index returns the index of the particle with the given ID.
+func (ps *ParticleStore) Index(id EntityID) (int, bool) {
+	i, ok := ps.index[id]
+	return i, ok
+}
+
+// Reset removes every particle from the store.
+func (ps *ParticleStore) Reset() {
+	ps.ID = ps.ID[:0]
+	ps.X, ps.Y = ps.X[:0], ps.Y[:0]
+	ps.VX, ps.VY = ps.VX[:0], ps.VY[:0]
+	ps.AX, ps.AY = ps.AX[:0], ps.AY[:0]
+	ps.R, ps.M = ps.R[:0], ps.M[:0]
+	ps.Kind = ps.Kind[:0]
+	ps.Disabled = ps.Disabled[:0]
+	ps.outside = ps.outside[:0]
+
+	for id := range ps.index {
+		delete(ps.index, id)
+	}
+}
+
+// Append adds a particle with the state of the entity to the end of the store.
+func (ps *ParticleStore) Append(e *Entity) {
+	if ps.index == nil {
+		ps.index = make(map[EntityID]int)
+	}
+	ps.index[e.ID] = len(ps.ID)
+
+	ps.ID = append(ps.ID, e.ID)
+	ps.X, ps.Y = append(ps.X, e.P.X), append(ps.Y, e.P.Y)
+	ps.VX, ps.VY = append(ps.VX, e.V.X), append(ps.VY, e.V.Y)
+	ps.AX, ps.AY = append(ps.AX, e.A.X), append(ps.AY, e.A.Y)
+	ps.R, ps.M = append(ps.R, e.R), append(ps.M, e.M)
+	ps.Kind = append(ps.Kind, e.Kind)
+	ps.Disabled = append(ps.Disabled, e.Disabled)
+	ps.outside = append(ps.outside, e.outside)
+}
+
+// Gather replaces the contents of the store with the state of each entity, in the same order as the list.
+func (ps *ParticleStore) Gather(entities EntityList) {
+	ps.Reset()
+	for _, e := range entities {
+		ps.Append(e)
+	}
+}
+
+// Scatter copies the state of each particle to the entity at the same index in the list.
+func (ps *ParticleStore) Scatter(entities EntityList) {
+	for i, e := range entities {
+		ps.Store(i, e)
+	}
+}
+
+// Load copies the state of the entity to the particle at index i.
+func (ps *ParticleStore) Load(i int, e *Entity) {
+	ps.X[i], ps.Y[i] = e.P.X, e.P.Y
+	ps.VX[i], ps.VY[i] = e.V.X, e.V.Y
+	ps.AX[i], ps.AY[i] = e.A.X, e.A.Y
+	ps.R[i], ps.M[i] = e.R, e.M
+	ps.Kind[i] = e.Kind
+	ps.Disabled[i] = e.Disabled
+	ps.outside[i] = e.outside
+}
+
+// Store copies the state of the particle at index i to the entity.
+func (ps *ParticleStore) Store(i int, e *Entity) {
+	e.P = internal.Vector{X: ps.X[i], Y: ps.Y[i]}
+	e.V = internal.Vector{X: ps.VX[i], Y: ps.VY[i]}
+	e.A = internal.Vector{X: ps.AX[i], Y: ps.AY[i]}
+	e.R, e.M = ps.R[i], ps.M[i]
+	e.Kind = ps.Kind[i]
+	e.Disabled = ps.Disabled[i]
+	e.outside = ps.outside[i]
+}
+
+// DeleteSweep removes every particle for which f returns true,
+// keeping the remaining particles in the same order like EntityList.DeleteSweep.
+// Returns the number of particles removed.
+func (ps *ParticleStore) DeleteSweep(f func(i int) bool) int {
+	var n int
+	for k := 0; k < len(ps.ID); k++ {
+		if f(k) {
+			delete(ps.index, ps.ID[k])
+			continue
+		}
+
+		ps.ID[n] = ps.ID[k]
+		ps.X[n], ps.Y[n] = ps.X[k], ps.Y[k]
+		ps.VX[n], ps.VY[n] = ps.VX[k], ps.VY[k]
+		ps.AX[n], ps.AY[n] = ps.AX[k], ps.AY[k]
+		ps.R[n], ps.M[n] = ps.R[k], ps.M[k]
+		ps.Kind[n] = ps.Kind[k]
+		ps.Disabled[n] = ps.Disabled[k]
+		ps.outside[n] = ps.outside[k]
+		ps.index[ps.ID[n]] = n
+		n++
+	}
+
+	removed := len(ps.ID) - n
+
+	ps.ID = ps.ID[:n]
+	ps.X, ps.Y = ps.X[:n], ps.Y[:n]
+	ps.VX, ps.VY = ps.VX[:n], ps.VY[:n]
+	ps.AX, ps.AY = ps.AX[:n], ps.AY[:n]
+	ps.R, ps.M = ps.R[:n], ps.M[:n]
+	ps.Kind = ps.Kind[:n]
+	ps.Disabled = ps.Disabled[:n]
+	ps.outside = ps.outside[:n]
+
+	return removed
+}
+
+// Object returns the position, velocity, acceleration, radius and mass of the particle at index i.
+func (ps *ParticleStore) Object(i int) physics.Object {
+	return physics.Object{
+		P: internal.Vector{X: ps.X[i], Y: ps.Y[i]},
+		V: internal.Vector{X: ps.VX[i], Y: ps.VY[i]},
+		A: internal.Vector{X: ps.AX[i], Y: ps.AY[i]},
+		R: ps.R[i],
+		M: ps.M[i],
+	}
+}
+
+// BoundingBox returns the bounding box of the particle at index i.
+func (ps *ParticleStore) BoundingBox(i int) internal.BoundingBox {
+	return internal.BoundingBox{
+		X: ps.X[i] - ps.R[i],
+		Y: ps.Y[i] - ps.R[i],
+		W: ps.R[i] * 2,
+		H: ps.R[i] * 2,
+	}
+}
+
+// treeBoundary returns the smallest square that encloses the position of every live particle, with padding,
+// and the maximum depth of a tree with that root.
+// It is the particle equivalent of treeBoundary.
+func (ps *ParticleStore) treeBoundary() (internal.BoundingBox, int) {
+	var min, max internal.Vector
+	var found bool
+	var radius = math.Inf(1)
+	for i := range ps.X {
+		x, y := ps.X[i], ps.Y[i]
+		if ps.Disabled[i] || math.IsNaN(x+y) || math.IsInf(x+y, 0) {
+			continue
+		}
+
+		if ps.R[i] > 0 {
+			radius = math.Min(radius, ps.R[i])
+		}
+
+		if !found {
+			min, max = internal.Vector{X: x, Y: y}, internal.Vector{X: x, Y: y}
+			found = true
+			continue
+		}
+
+		min.X = math.Min(min.X, x)
+		min.Y = math.Min(min.Y, y)
+		max.X = math.Max(max.X, x)
+		max.Y = math.Max(max.Y, y)
+	}
+
+	box := paddedSquare(min, max)
+	return box, treeDepth(box.W, radius)
+}
+
+// kick accelerates the velocity of every particle by its acceleration over the timestep.
+func (ps *ParticleStore) kick(timestep float64) {
+	for i := range ps.VX {
+		ps.VX[i] = ps.VX[i] + (ps.AX[i] * timestep)
+		ps.VY[i] = ps.VY[i] + (ps.AY[i] * timestep)
+	}
+}
+
+// drift moves every particle at its velocity over the timestep.
+func (ps *ParticleStore) drift(timestep float64) {
+	for i := range ps.X {
+		ps.X[i] = ps.X[i] + (ps.VX[i] * timestep)
+		ps.Y[i] = ps.Y[i] + (ps.VY[i] * timestep)
+	}
+}
+
+// particleIntegrator returns the integrator used to run the next step on the particle store,
+// or false if particle storage is disabled or the step must be run on the entities.
+// Particles are stepped by built-in integrators in the fixed timestep mode without continuous collision detection.
+func (s *Simulation) particleIntegrator() (particleIntegrator, bool) {
+	if s.particles == nil || s.timestepMode != FixedTimestep || s.continuousCollisions {
+		return nil, false
+	}
+
+	integrator, ok := s.integrator.(particleIntegrator)
+	return integrator, ok
+}
+
+// syncEntities copies the state of the particle store to the entities if particles have moved since they were last copied.
+func (s *Simulation) syncEntities() {
+	if !s.entitiesStale {
+		return
+	}
+
+	s.particles.Scatter(s.entities)
+	s.entitiesStale = false
+}
+
+// releaseParticles makes the entities the state of the simulation because they may be modified directly.
+// The particle store is gathered from the entities again before the next particle step.
+func (s *Simulation) releaseParticles() {
+	s.syncEntities()
+	s.particlesCurrent = false
+}
+
+// loadParticle copies the state of an entity that has been changed through the simulation's API to its particle.
+func (s *Simulation) loadParticle(e *Entity) {
+	if !s.particlesCurrent {
+		return
+	}
+
+	if i, ok := s.particles.Index(e.ID); ok {
+		s.particles.Load(i, e)
+	}
+}
+
+// integrateParticles advances every particle by the timestep using the integrator and then resolves collisions.
+func (s *Simulation) integrateParticles(integrator particleIntegrator, timestep float64) {
+	if !s.particlesCurrent {
+		s.particles.Gather(s.entities)
+		s.particlesCurrent = true
+	}
+	s.entitiesStale = true
+
+	s.prepareParticles(timestep)
+
+	s.stats.Substeps++
+	integrator.IntegrateParticles(s.particles, timestep, s.accelerateParticles)
+	s.applyParticleBoundary()
+
+	s.collideParticles()
+}
+
+// prepareParticles sets the velocity of every particle that is not dynamic at the start of a step like prepareBodies.
+func (s *Simulation) prepareParticles(timestep float64) {
+	ps := s.particles
+	for i, kind := range ps.Kind {
+		switch kind {
+		case BodyKindStatic:
+			ps.VX[i], ps.VY[i] = 0, 0
+			ps.AX[i], ps.AY[i] = 0, 0
+		case BodyKindKinematic:
+			ps.AX[i], ps.AY[i] = 0, 0
+
+			path := s.entities[i].Path
+			if path == nil {
+				continue
+			}
+
+			p := path.Position(s.Time())
+			ps.VX[i] = (p.X - ps.X[i]) / timestep
+			ps.VY[i] = (p.Y - ps.Y[i]) / timestep
+		}
+	}
+}
+
+// accelerateParticles calculates the acceleration of every dynamic particle from a tree built over the particle store.
+func (s *Simulation) accelerateParticles() {
+	ps := s.particles
+	boundary, depth := ps.treeBoundary()
+	s.particleTree.Build(ps, boundary, treeMaxEntries, depth)
+
+	offsets := s.imageOffsets()
+
+	perf := time.Now()
+	parallel(s.workers, ps.Len(), func(start, end int) {
+		for i := start; i < end; i++ {
+			if ps.Disabled[i] || ps.Kind[i] != BodyKindDynamic {
+				continue
+			}
+
+			var a internal.Vector
+			for _, offset := range offsets {
+				f := s.particleTree.AccelerationAt(ps, i, internal.Vector{X: ps.X[i] + offset.X, Y: ps.Y[i] + offset.Y}, s.theta, s.gravity)
+				a.X += f.X
+				a.Y += f.Y
+			}
+
+			ps.AX[i], ps.AY[i] = a.X, a.Y
+		}
+	})
+	s.stats.Interactions += time.Now().Sub(perf)
+}
+
+// applyParticleBoundary applies the boundary mode to every particle after they have moved like applyBoundary.
+func (s *Simulation) applyParticleBoundary() {
+	box := s.worldBoundary
+	if box.W <= 0 || box.H <= 0 {
+		return
+	}
+
+	ps := s.particles
+	for i := range ps.X {
+		if ps.Disabled[i] {
+			continue
+		}
+
+		outside := !box.ContainsPoint(internal.Vector{X: ps.X[i], Y: ps.Y[i]})
+		if outside && !ps.outside[i] && s.emitting() {
+			e := s.entities[i]
+			ps.Store(i, e)
+			s.emit(BoundaryExitEvent{Step: s.steps, Entity: e, Mode: s.boundaryMode})
+		}
+
+		switch s.boundaryMode {
+		case BoundaryModeReflect:
+			o := ps.Object(i)
+			o.ReflectBounds(box)
+			ps.X[i], ps.Y[i] = o.P.X, o.P.Y
+			ps.VX[i], ps.VY[i] = o.V.X, o.V.Y
+		case BoundaryModeWrap:
+			ps.X[i] = box.X + wrap(ps.X[i]-box.X, box.W)
+			ps.Y[i] = box.Y + wrap(ps.Y[i]-box.Y, box.H)
+		case BoundaryModeAbsorb:
+			if outside {
+				e := s.entities[i]
+				ps.Disabled[i] = true
+				ps.Store(i, e)
+				if s.emitting() {
+					s.emit(RemovedEvent{Step: s.steps, Entity: e, Cause: EventCauseBoundary})
+				}
+			}
+		}
+
+		ps.outside[i] = !box.ContainsPoint(internal.Vector{X: ps.X[i], Y: ps.Y[i]})
+	}
+}
+
+// collideParticles resolves every collision between live particles like ResolveCollisions.
+// The state of each pair of colliding particles is copied to their entities so that the collision resolver
+// can be given the entities, and copied back once the collision has been resolved.
+func (s *Simulation) collideParticles() {
+	perf := time.Now()
+
+	ps := s.particles
+	boundary, depth := ps.treeBoundary()
+	s.particleTree.Build(ps, boundary, treeMaxEntries, depth)
+
+	var collisionMap = make(entityCollisionMap)
+	var created EntityList
+
+	for i, n := 0, ps.Len(); i < n; i++ {
+		if ps.Disabled[i] {
+			continue
+		}
+
+		s.particleTree.Intersections(ps.BoundingBox(i), func(k int) bool {
+			if ps.Disabled[k] || i == k {
+				return true
+			}
+
+			distance, colliding := physics.Colliding(ps.Object(i), ps.Object(k))
+			if !colliding {
+				return true
+			}
+
+			e1, e2 := s.entities[i], s.entities[k]
+			if collisionMap.check(e1, e2) {
+				return true
+			}
+
+			collisionMap.store(e1, e2)
+
+			ps.Store(i, e1)
+			ps.Store(k, e2)
+			created = append(created, s.resolve(s.collisionResolver, e1, e2, distance)...)
+			ps.Load(i, e1)
+			ps.Load(k, e2)
+
+			return !ps.Disabled[i]
+		})
+	}
+
+	// Entities created by the resolver take part in the next step
+	s.appendCreated(created)
+	s.stats.Collisions += time.Now().Sub(perf)
+
+	s.deleteDisabled()
+}
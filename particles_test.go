@@ -0,0 +1,204 @@
+package universe
+
+import (
+	"github.com/relvacode/universe/internal"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// benchmarkEntities is the number of entities stepped by the storage benchmarks.
+const benchmarkEntities = 50000
+
+func newBenchmarkSimulation(particles bool) *Simulation {
+	box := internal.BoundingBox{W: 16384, H: 16384}
+	rng := rand.New(rand.NewSource(1))
+
+	s := NewSimulation(box)
+	s.SetParticleStorage(particles)
+
+	for i := 0; i < benchmarkEntities; i++ {
+		s.Add(NewEntity(
+			internal.Vector{
+				X: box.X + box.W*rng.Float64(),
+				Y: box.Y + box.H*rng.Float64(),
+			},
+			internal.Vector{},
+			1,
+		))
+	}
+
+	return s
+}
+
+func benchmarkStep(b *testing.B, particles bool) {
+	s := newBenchmarkSimulation(particles)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Step(1)
+	}
+}
+
+func BenchmarkStepEntities(b *testing.B) {
+	benchmarkStep(b, false)
+}
+
+func BenchmarkStepParticles(b *testing.B) {
+	benchmarkStep(b, true)
+}
+
+// TestParticleStorageAcceleration checks that the particle tree calculates the same acceleration as the entity tree.
+func TestParticleStorageAcceleration(t *testing.T) {
+	accelerations := func(particles bool) []internal.Vector {
+		s := newBenchmarkSimulation(particles)
+
+		a := make([]internal.Vector, len(s.entities))
+		if particles {
+			s.particles.Gather(s.entities)
+			s.accelerateParticles()
+			for i := range a {
+				a[i] = internal.Vector{X: s.particles.AX[i], Y: s.particles.AY[i]}
+			}
+			return a
+		}
+
+		s.accelerate()
+		for i, e := range s.entities {
+			a[i] = e.A
+		}
+		return a
+	}
+
+	// The trees sum the same interactions in a different order, so only rounding errors are allowed
+	const tolerance = 1e-9
+
+	entities, particles := accelerations(false), accelerations(true)
+	for i := range entities {
+		dx, dy := entities[i].X-particles[i].X, entities[i].Y-particles[i].Y
+		if math.Sqrt(dx*dx+dy*dy) > tolerance*math.Sqrt(entities[i].Dot()) {
+			t.Fatalf("entity %d: acceleration %v with entities, %v with particles", i, entities[i], particles[i])
+		}
+	}
+}
+
+func newOrbitSimulation(particles bool, integrator Integrator) *Simulation {
+	box := internal.BoundingBox{W: 1000, H: 1000}
+	rng := rand.New(rand.NewSource(1))
+
+	s := NewSimulation(box)
+	s.SetIntegrator(integrator)
+	s.SetParticleStorage(particles)
+
+	for i := 0; i < 200; i++ {
+		s.Add(NewEntity(
+			internal.Vector{
+				X: box.W * rng.Float64(),
+				Y: box.H * rng.Float64(),
+			},
+			internal.Vector{
+				X: rng.Float64() - 0.5,
+				Y: rng.Float64() - 0.5,
+			},
+			0.5,
+		))
+	}
+
+	return s
+}
+
+// TestParticleStorageStep checks that stepping the particle store moves entities like stepping the entities.
+func TestParticleStorageStep(t *testing.T) {
+	for _, name := range []string{"euler", "leapfrog", "verlet", "rk4"} {
+		t.Run(name, func(t *testing.T) {
+			var positions [2][]internal.Vector
+			for k, particles := range []bool{false, true} {
+				integrator, _ := IntegratorByName(name)
+				s := newOrbitSimulation(particles, integrator)
+				s.Step(50)
+
+				for _, e := range *s.Entities() {
+					positions[k] = append(positions[k], e.P)
+				}
+			}
+
+			const tolerance = 1e-6
+			for i := range positions[0] {
+				dx, dy := positions[0][i].X-positions[1][i].X, positions[0][i].Y-positions[1][i].Y
+				if math.Sqrt(dx*dx+dy*dy) > tolerance {
+					t.Fatalf("entity %d: position %v with entities, %v with particles", i, positions[0][i], positions[1][i])
+				}
+			}
+		})
+	}
+}
+
+// TestParticleStorageCollisions checks that collisions between particles are resolved and removed entities are deleted from the store.
+func TestParticleStorageCollisions(t *testing.T) {
+	s := NewSimulation(internal.BoundingBox{W: 1000, H: 1000})
+	s.SetParticleStorage(true)
+	s.SetCollisionResolver(AbsorbCollisionResolver{})
+
+	// Pairs of overlapping entities far apart from each other
+	for i := 0; i < 10; i++ {
+		x := float64(i) * 100
+		s.Add(
+			NewEntity(internal.Vector{X: x, Y: 500}, internal.Vector{}, 2),
+			NewEntity(internal.Vector{X: x + 0.1, Y: 500}, internal.Vector{}, 2),
+		)
+	}
+
+	s.Step(1)
+
+	if s.Len() != 10 || s.particles.Len() != 10 {
+		t.Fatalf("expected 10 entities and particles after merging, got %d and %d", s.Len(), s.particles.Len())
+	}
+
+	for i, e := range s.entities {
+		if s.particles.ID[i] != e.ID {
+			t.Fatalf("particle %d has ID %d, expected entity ID %d", i, s.particles.ID[i], e.ID)
+		}
+		if j, ok := s.particles.Index(e.ID); !ok || j != i {
+			t.Fatalf("entity %d is at index %d in the store, expected %d", e.ID, j, i)
+		}
+		if s.particles.M[i] != 16 {
+			t.Fatalf("particle %d has mass %g, expected 16", i, s.particles.M[i])
+		}
+	}
+}
+
+// TestParticleStorageSync checks that entities and particles stay in sync when the simulation is modified between steps.
+func TestParticleStorageSync(t *testing.T) {
+	s := newOrbitSimulation(true, EulerIntegrator{})
+	s.Step(1)
+
+	if !s.particlesCurrent || !s.entitiesStale {
+		t.Fatal("expected the particle store to hold the state of the simulation after a step")
+	}
+
+	first := s.entities[0]
+	s.SetVelocity(first, internal.Vector{X: 10})
+	if s.particles.VX[0] != 10 || s.particles.X[0] != first.P.X {
+		t.Fatalf("particle not updated by SetVelocity: %v", s.particles.Object(0))
+	}
+
+	removed := s.entities[1].ID
+	s.RemoveByID(removed)
+	if _, ok := s.particles.Index(removed); ok || s.particles.Len() != s.Len() {
+		t.Fatal("removed entity is still in the particle store")
+	}
+
+	s.Step(1)
+
+	snapshot, err := s.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, se := range snapshot.Entities {
+		if se.P.X != s.particles.X[i] || se.P.Y != s.particles.Y[i] {
+			t.Fatalf("entity %d: snapshot position %v, particle position %v, %v", i, se.P, s.particles.X[i], s.particles.Y[i])
+		}
+	}
+}
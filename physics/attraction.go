@@ -9,7 +9,140 @@ const (
 	G = 8.8e-1
 )
 
-func AttractionForceVector(p1, p2 internal.Vector, m1, m2 float64, force float64) internal.Vector {
+// ForceLaw calculates the magnitude of the attraction between two unit masses separated by distance.
+type ForceLaw interface {
+	Force(distance, softening float64) float64
+}
+
+// PotentialLaw is implemented by a ForceLaw that can calculate the potential between two unit masses separated by distance.
+// The potential is the integral of the force with respect to distance, such that it increases with distance for an attractive force.
+type PotentialLaw interface {
+	Potential(distance, softening float64) float64
+}
+
+// ForceLawFunc is a user supplied ForceLaw.
+type ForceLawFunc func(distance, softening float64) float64
+
+func (f ForceLawFunc) Force(distance, softening float64) float64 {
+	return f(distance, softening)
+}
+
+// InverseSquareLaw is Newtonian gravity where the force falls off with 1/r².
+type InverseSquareLaw struct{}
+
+func (InverseSquareLaw) Force(distance, _ float64) float64 {
+	return 1 / (distance * distance)
+}
+
+func (InverseSquareLaw) Potential(distance, _ float64) float64 {
+	return -1 / distance
+}
+
+// InverseLinearLaw is true two dimensional gravity derived from a logarithmic potential where the force falls off with 1/r.
+type InverseLinearLaw struct{}
+
+func (InverseLinearLaw) Force(distance, _ float64) float64 {
+	return 1 / distance
+}
+
+func (InverseLinearLaw) Potential(distance, _ float64) float64 {
+	return math.Log(distance)
+}
+
+// PlummerLaw is Newtonian gravity softened by treating each body as a Plummer sphere with a radius of the softening length.
+type PlummerLaw struct{}
+
+func (PlummerLaw) Force(distance, softening float64) float64 {
+	return distance / math.Pow(distance*distance+softening*softening, 1.5)
+}
+
+func (PlummerLaw) Potential(distance, softening float64) float64 {
+	return -1 / math.Sqrt(distance*distance+softening*softening)
+}
+
+// SoftenedInverseLinearLaw is two dimensional gravity softened over the softening length.
+type SoftenedInverseLinearLaw struct{}
+
+func (SoftenedInverseLinearLaw) Force(distance, softening float64) float64 {
+	return distance / (distance*distance + softening*softening)
+}
+
+func (SoftenedInverseLinearLaw) Potential(distance, softening float64) float64 {
+	return 0.5 * math.Log(distance*distance+softening*softening)
+}
+
+// potentialIntervals is the number of intervals used to integrate the potential of a ForceLaw that is not a PotentialLaw.
+const potentialIntervals = 64
+
+// integratePotential calculates the potential of a force law by integrating its force from a unit distance using Simpson's rule.
+// The integral is taken over the logarithm of distance so that the intervals are evenly spread for forces that fall off with distance.
+// The result differs from the true potential by a constant which does not affect changes in energy.
+func integratePotential(law ForceLaw, distance, softening float64) float64 {
+	integrand := func(t float64) float64 {
+		r := math.Exp(t)
+		return law.Force(r, softening) * r
+	}
+
+	h := math.Log(distance) / potentialIntervals
+	sum := integrand(0) + integrand(math.Log(distance))
+	for i := 1; i < potentialIntervals; i++ {
+		w := 2.
+		if i%2 == 1 {
+			w = 4
+		}
+		sum += w * integrand(float64(i)*h)
+	}
+
+	return sum * h / 3
+}
+
+// ForceLawByName returns the built-in force law with the given name.
+func ForceLawByName(name string) (ForceLaw, bool) {
+	switch name {
+	case "inverse-square":
+		return InverseSquareLaw{}, true
+	case "inverse-linear":
+		return InverseLinearLaw{}, true
+	case "plummer":
+		return PlummerLaw{}, true
+	case "softened-inverse-linear":
+		return SoftenedInverseLinearLaw{}, true
+	}
+
+	return nil, false
+}
+
+// ForceLawName returns the name of a built-in force law.
+func ForceLawName(law ForceLaw) (string, bool) {
+	switch law.(type) {
+	case InverseSquareLaw:
+		return "inverse-square", true
+	case InverseLinearLaw:
+		return "inverse-linear", true
+	case PlummerLaw:
+		return "plummer", true
+	case SoftenedInverseLinearLaw:
+		return "softened-inverse-linear", true
+	}
+
+	return "", false
+}
+
+// DefaultGravity is true two dimensional gravity with the default gravitational constant.
+var DefaultGravity = Gravity{
+	Law: InverseLinearLaw{},
+	G:   G,
+}
+
+// Gravity describes how bodies attract each other.
+type Gravity struct {
+	Law       ForceLaw
+	G         float64
+	Softening float64
+}
+
+// ForceVector calculates the force acting on a body with mass m1 at p1 towards a body with mass m2 at p2.
+func (g Gravity) ForceVector(p1, p2 internal.Vector, m1, m2 float64) internal.Vector {
 	direction := internal.Vector{
 		X: p2.X - p1.X,
 		Y: p2.Y - p1.Y,
@@ -25,7 +158,7 @@ func AttractionForceVector(p1, p2 internal.Vector, m1, m2 float64, force float64
 		Y: direction.Y / distance,
 	}
 
-	attraction := force * ((m1 * m2) / distance)
+	attraction := g.G * m1 * m2 * g.Law.Force(distance, g.Softening)
 
 	return internal.Vector{
 		X: attraction * normal.X,
@@ -33,6 +166,34 @@ func AttractionForceVector(p1, p2 internal.Vector, m1, m2 float64, force float64
 	}
 }
 
+// Acceleration calculates the acceleration of a body at p1 towards a body with mass m2 at p2.
+func (g Gravity) Acceleration(p1, p2 internal.Vector, m2 float64) internal.Vector {
+	return g.ForceVector(p1, p2, 1, m2)
+}
+
+// Potential calculates the gravitational potential energy per unit mass of a body at p1 caused by a body with mass m2 at p2.
+func (g Gravity) Potential(p1, p2 internal.Vector, m2 float64) float64 {
+	direction := internal.Vector{
+		X: p2.X - p1.X,
+		Y: p2.Y - p1.Y,
+	}
+
+	distance := math.Sqrt(direction.Dot())
+	if distance == 0 {
+		return 0
+	}
+
+	if law, ok := g.Law.(PotentialLaw); ok {
+		return g.G * m2 * law.Potential(distance, g.Softening)
+	}
+
+	return g.G * m2 * integratePotential(g.Law, distance, g.Softening)
+}
+
+func AttractionForceVector(p1, p2 internal.Vector, m1, m2 float64, force float64) internal.Vector {
+	return Gravity{Law: InverseLinearLaw{}, G: force}.ForceVector(p1, p2, m1, m2)
+}
+
 func AttractionForceImpulse(p1, p2 internal.Vector, m1, m2 float64, timestep float64) internal.Vector {
 	force := AttractionForceVector(p1, p2, m1, m2, G)
 	return internal.Vector{
@@ -16,24 +16,67 @@ func Colliding(o1, o2 Object) (float64, bool) {
 	return dist, dist < o1.R+o2.R
 }
 
-// CollisionTime calculates the timestep at which the two objects will collide
+// CollisionTime calculates the time at which two objects moving at their current velocities first touch.
+// If the objects already overlap the time is zero or negative, the time in the past at which they first touched.
+// Returns +Inf if the objects never touch.
 func CollisionTime(n1, n2 Object) float64 {
 	delta := internal.Vector{
 		X: n2.P.X - n1.P.X,
 		Y: n2.P.Y - n1.P.Y,
 	}
 
-	dist := math.Sqrt(delta.Dot())
-	dist = dist - (n1.R + n2.R) // The remaining distance
-
 	// combined velocity
 	vec := internal.Vector{
 		X: n2.V.X - n1.V.X,
 		Y: n2.V.Y - n1.V.Y,
 	}
 
-	speed := math.Sqrt(vec.Dot())
-	return dist / speed
+	// Solve |delta + vec*t| = n1.R + n2.R for t
+	r := n1.R + n2.R
+	a := vec.Dot()
+	b := 2 * (delta.X*vec.X + delta.Y*vec.Y)
+	c := delta.Dot() - r*r
+
+	if a == 0 {
+		if c <= 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+
+	discriminant := b*b - 4*a*c
+	if discriminant < 0 {
+		return math.Inf(1)
+	}
+
+	t := (-b - math.Sqrt(discriminant)) / (2 * a)
+	if c > 0 && t < 0 {
+		// The objects touched in the past and are moving apart
+		return math.Inf(1)
+	}
+
+	return t
+}
+
+// ClosestApproachTime calculates the time at which two objects moving at their current velocities are closest.
+// The time is negative if the objects are moving apart.
+func ClosestApproachTime(n1, n2 Object) float64 {
+	delta := internal.Vector{
+		X: n2.P.X - n1.P.X,
+		Y: n2.P.Y - n1.P.Y,
+	}
+
+	vec := internal.Vector{
+		X: n2.V.X - n1.V.X,
+		Y: n2.V.Y - n1.V.Y,
+	}
+
+	speed := vec.Dot()
+	if speed == 0 {
+		return 0
+	}
+
+	return -(delta.X*vec.X + delta.Y*vec.Y) / speed
 }
 
 //func (c Collision) Move(o1, o2 *Object) {
@@ -97,3 +140,26 @@ func Reflect(o1, o2 *Object) {
 	o2.V.X = t*-cp + v2s*-cpp
 	o2.V.Y = t*-sp + v2s*-spp
 }
+
+// ReflectImmovable reflects the velocity of o off an immovable object in a perfectly elastic collision.
+// The velocity of o relative to the immovable object is reversed along the line between their centres.
+func ReflectImmovable(o *Object, immovable Object) {
+	delta := internal.Vector{
+		X: immovable.P.X - o.P.X,
+		Y: immovable.P.Y - o.P.Y,
+	}
+
+	dist := math.Sqrt(delta.Dot())
+	if dist == 0 {
+		return
+	}
+
+	normal := internal.Vector{
+		X: delta.X / dist,
+		Y: delta.Y / dist,
+	}
+
+	approach := (o.V.X-immovable.V.X)*normal.X + (o.V.Y-immovable.V.Y)*normal.Y
+	o.V.X -= 2 * approach * normal.X
+	o.V.Y -= 2 * approach * normal.Y
+}
@@ -8,14 +8,24 @@ import (
 type Object struct {
 	P internal.Vector
 	V internal.Vector
+	A internal.Vector
 	R float64
 	M float64
 }
 
 func (o *Object) KineticEnergy() float64 {
-	return 0.5 * o.M * math.Pow(math.Abs(o.V.X)+math.Abs(o.V.Y), 2)
+	return 0.5 * o.M * o.V.Dot()
 }
 
+func (o *Object) Momentum() internal.Vector {
+	return internal.Vector{
+		X: o.M * o.V.X,
+		Y: o.M * o.V.Y,
+	}
+}
+
+// ReflectBounds keeps the object inside the bounding box.
+// The object is moved back inside any edge it has crossed and its velocity is reflected away from that edge.
 func (o *Object) ReflectBounds(bb internal.BoundingBox) {
 	var (
 		top    = bb.Y
@@ -24,18 +34,20 @@ func (o *Object) ReflectBounds(bb internal.BoundingBox) {
 		bottom = bb.Y + bb.H
 	)
 	switch {
-	case o.P.Y < top+o.R:
-		o.P.Y = top + o.R
-		o.V.Y *= -1
+	case o.P.X < left+o.R:
+		o.P.X = left + o.R
+		o.V.X = math.Abs(o.V.X)
 	case o.P.X > right-o.R:
 		o.P.X = right - o.R
-		o.V.X *= -1
+		o.V.X = -math.Abs(o.V.X)
+	}
+	switch {
+	case o.P.Y < top+o.R:
+		o.P.Y = top + o.R
+		o.V.Y = math.Abs(o.V.Y)
 	case o.P.Y > bottom-o.R:
 		o.P.Y = bottom - o.R
-		o.V.Y *= -1
-	case o.P.X < left+o.R:
-		o.P.X = left + o.R
-		o.V.X *= -1
+		o.V.Y = -math.Abs(o.V.Y)
 	}
 }
 
@@ -48,6 +60,12 @@ func (o *Object) BoundingBox() internal.BoundingBox {
 	}
 }
 
+// Kick accelerates the velocity of the object by its current acceleration over the timestep.
+func (o *Object) Kick(timestep float64) {
+	o.V.X = o.V.X + (o.A.X * timestep)
+	o.V.Y = o.V.Y + (o.A.Y * timestep)
+}
+
 func (o *Object) Step(timestep float64) {
 	o.P.X = o.P.X + (o.V.X * timestep)
 	o.P.Y = o.P.Y + (o.V.Y * timestep)
@@ -1,3 +1,5 @@
+// +build js
+
 package universe
 
 import (
@@ -8,8 +10,6 @@ import (
 	"time"
 )
 
-const PhysicsConstantTimestep = 0.0165 // 60FPS
-
 func NewRenderer(
 	entityContext, viewContext, debugContext *draw.Context,
 	worldBoundary internal.BoundingBox, view View) *Renderer {
@@ -19,11 +19,12 @@ func NewRenderer(
 		contextDebug:    debugContext,
 		worldBoundary:   worldBoundary,
 
-		simulation: NewSimulation(worldBoundary),
+		simulation: view.Simulation(),
 		view:       view,
 	}
 }
 
+// Renderer is a browser adapter that advances a Simulation on each animation frame and draws the result.
 type Renderer struct {
 	contextEntities *draw.Context
 	contextView     *draw.Context
@@ -33,63 +34,54 @@ type Renderer struct {
 
 	simulation *Simulation
 
-	timeStepRemaining float64
-	frame             int
+	frame int
 
 	perfPhysicsIterations int
-	perfInteractions      time.Duration
-	perfCollisions        time.Duration
 	perfPhysics           time.Duration
 	perfDraw              time.Duration
 }
 
-func (r *Renderer) reset(ctx *draw.Context) {
+func (r *Renderer) reset(ctx draw.Canvas) {
 	ctx.ClearRect(r.worldBoundary.X, r.worldBoundary.Y, r.worldBoundary.W, r.worldBoundary.H)
 }
 
-func (r *Renderer) fps(timestep float64, ctx *draw.Context) {
+func (r *Renderer) fps(timestep float64, ctx draw.Canvas) {
 	r.frame++
 	var y float64 = 20
 	h := ctx.MeasureFontHeight() + 2
 
+	stats := r.simulation.Stats()
+
 	ctx.FillText(fmt.Sprintf("%.3fms, %.2ffps, frame %d, %dit", timestep, 1000/timestep, r.frame, r.perfPhysicsIterations), 10, y)
 	y += h
 
-	ctx.FillText(fmt.Sprintf("physics %s", r.perfPhysics), 10, y)
+	ctx.FillText(fmt.Sprintf("physics %s, %d substeps", r.perfPhysics, stats.Substeps), 10, y)
 	y += h
 
-	ctx.FillText(fmt.Sprintf("collisions %s", r.perfCollisions), 20, y)
+	ctx.FillText(fmt.Sprintf("collisions %s", stats.Collisions), 20, y)
 	y += h
 
-	ctx.FillText(fmt.Sprintf("interactions %s", r.perfInteractions), 20, y)
+	ctx.FillText(fmt.Sprintf("interactions %s", stats.Interactions), 20, y)
 	y += h
 
 	ctx.FillText(fmt.Sprintf("draw %s", r.perfDraw), 10, y)
 	y += h
-}
 
-func (r *Renderer) runConstantTimeStep(timestep float64, entities EntityList) {
-	if len(entities) == 0 {
+	diagnostics, baseline := r.simulation.Diagnostics()
+	if diagnostics.Entities == 0 {
 		return
 	}
 
-	perf := time.Now()
-
-	visible, invisible := r.simulation.CompileTree(entities)
+	energyDrift, momentumDrift, angularMomentumDrift := diagnostics.Drift(baseline)
 
-	perf = time.Now()
-	r.simulation.Interact(timestep, invisible)
-	r.perfInteractions = time.Now().Sub(perf)
-
-	for _, o := range entities {
-		o.Step(timestep)
-	}
+	ctx.FillText(fmt.Sprintf("energy %.4g (kinetic %.4g, potential %.4g), drift %+.2e", diagnostics.Energy(), diagnostics.KineticEnergy, diagnostics.PotentialEnergy, energyDrift), 10, y)
+	y += h
 
-	perf = time.Now()
-	r.simulation.ResolveCollisions(visible, r.view.CollisionResolver())
-	r.perfCollisions = time.Now().Sub(perf)
+	ctx.FillText(fmt.Sprintf("momentum %.4g, %.4g, drift %.2e", diagnostics.Momentum.X, diagnostics.Momentum.Y, momentumDrift), 10, y)
+	y += h
 
-	return
+	ctx.FillText(fmt.Sprintf("angular momentum %.4g, drift %+.2e", diagnostics.AngularMomentum, angularMomentumDrift), 10, y)
+	y += h
 }
 
 func (r *Renderer) Render(timestep float64) {
@@ -103,46 +95,26 @@ func (r *Renderer) Render(timestep float64) {
 		return
 	}
 
-	timeScale := ((timestep / 1000) * r.view.TimeScale()) + r.timeStepRemaining
-	entities := r.view.Entities()
+	// Advance by a nominal frame time so that the number of steps does not depend on the frame rate
+	if r.simulation.Deterministic() {
+		timestepSecs = PhysicsConstantTimestep
+	}
 
 	var perf time.Time
 
 	var physicsIterations int
-	if timeScale > 0 {
+	if timeScale := r.view.TimeScale(); timeScale > 0 {
 		perf = time.Now()
-
-		// Run physics for as long as at least one full iteration can run
-		for ; timeScale >= PhysicsConstantTimestep; timeScale -= PhysicsConstantTimestep {
-			r.runConstantTimeStep(PhysicsConstantTimestep, *entities)
-			physicsIterations++
-		}
-
-		// Any remaining time is deferred to the next frame
-		r.timeStepRemaining = timeScale
+		physicsIterations = r.simulation.Advance(timestepSecs * timeScale)
 		r.perfPhysics = time.Now().Sub(perf)
 	}
 
 	r.perfPhysicsIterations = physicsIterations
 
-	entities.DeleteSweep(func(e *Entity) bool {
-		return e.Disabled
-	})
-
 	perf = time.Now()
 	r.reset(r.contextEntities)
 
-	camera := r.view.Camera()
-	cameraBounds := camera.Crop(r.worldBoundary)
-
-	for _, o := range *entities {
-		// Do not draw entities that are not within the bounds of the current camera
-		if !cameraBounds.Intersects(o.BoundingBox()) {
-			continue
-		}
-
-		o.Draw(r.contextEntities, *camera)
-	}
+	r.simulation.Draw(r.contextEntities, *r.view.Camera())
 
 	r.view.Draw(r.contextView)
 
@@ -175,6 +147,7 @@ func (r *Renderer) Loop() {
 
 func (r *Renderer) Update(box internal.BoundingBox) {
 	r.worldBoundary = box
+	r.simulation.SetWorldBoundary(box)
 
 	r.contextEntities.Resize(box.W, box.H)
 	r.contextView.Resize(box.W, box.H)
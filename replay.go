@@ -0,0 +1,236 @@
+package universe
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/relvacode/universe/internal"
+	"io"
+)
+
+//go:generate go run github.com/abice/go-enum -f=$GOFILE --marshal
+
+// ReplayEventKind is the type of input recorded in a replay.
+/*
+ENUM(
+spawn,
+velocity,
+remove,
+clear,
+update,
+physics
+)
+*/
+type ReplayEventKind uint8
+
+// ReplayVersion is the version of replays created by this package.
+const ReplayVersion = 1
+
+// ReplayEvent is a single input applied to the simulation before the given step.
+// Entities are referenced by their ID.
+type ReplayEvent struct {
+	Step int             `json:"step"`
+	Kind ReplayEventKind `json:"kind"`
+
+	// Entity is the spawned entity, or the new state of an updated entity
+	Entity *SnapshotEntity `json:"entity,omitempty"`
+	// ID is the entity whose velocity was changed
+	ID EntityID `json:"id,omitempty"`
+	// V is the new velocity
+	V internal.Vector `json:"v,omitempty"`
+	// IDs are the removed entities
+	IDs []EntityID `json:"ids,omitempty"`
+	// Physics are the new physics parameters of the simulation
+	Physics *SnapshotPhysics `json:"physics,omitempty"`
+}
+
+// Replay is a recording of every input applied to a simulation from an initial snapshot.
+// Because each step is deterministic, playing a replay reproduces the recorded session exactly.
+type Replay struct {
+	Version int           `json:"version"`
+	Seed    int64         `json:"seed"`
+	Initial *Snapshot     `json:"initial"`
+	Events  []ReplayEvent `json:"events"`
+}
+
+// SaveReplay writes the replay to w as JSON.
+func SaveReplay(w io.Writer, replay *Replay) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(replay)
+}
+
+// LoadReplay reads a JSON replay from r.
+func LoadReplay(r io.Reader) (*Replay, error) {
+	replay := new(Replay)
+	if err := json.NewDecoder(r).Decode(replay); err != nil {
+		return nil, err
+	}
+
+	if replay.Version != ReplayVersion {
+		return nil, fmt.Errorf("unsupported replay version %d", replay.Version)
+	}
+
+	if replay.Initial == nil {
+		return nil, fmt.Errorf("replay has no initial snapshot")
+	}
+
+	for _, event := range replay.Events {
+		if event.Kind != ReplayEventKindPhysics {
+			continue
+		}
+		if event.Physics == nil {
+			return nil, fmt.Errorf("physics event at step %d has no parameters", event.Step)
+		}
+		if _, err := event.Physics.decode(); err != nil {
+			return nil, fmt.Errorf("physics event at step %d: %s", event.Step, err)
+		}
+	}
+
+	return replay, nil
+}
+
+// StartRecording begins recording every input applied to the simulation
+// through Add, SetVelocity, UpdateByID, SetMetadataByID, SetKindByID, Remove and Clear,
+// and every change to the physics parameters that are part of a snapshot.
+func (s *Simulation) StartRecording() error {
+	initial, err := s.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	// Reseed so that the random source during playback matches the recorded session,
+	// regardless of how many values were drawn before recording started
+	s.SetSeed(s.rng.Int63())
+
+	s.recording = &Replay{
+		Version: ReplayVersion,
+		Seed:    s.seed,
+		Initial: initial,
+	}
+	s.recordingErr = nil
+
+	return nil
+}
+
+// StopRecording stops recording and returns the recorded replay.
+// Returns nil if the simulation was not being recorded.
+// An error is returned instead if an integrator, force law or collision resolver
+// that is not built-in was set while recording, because the replay cannot reproduce it.
+func (s *Simulation) StopRecording() (*Replay, error) {
+	replay, err := s.recording, s.recordingErr
+	s.recording, s.recordingErr = nil, nil
+	if err != nil {
+		return nil, err
+	}
+	return replay, nil
+}
+
+func (s *Simulation) Recording() bool {
+	return s.recording != nil
+}
+
+// Play restores the simulation to the initial state of the replay.
+// Recorded events are applied as the simulation reaches the step they were recorded at.
+func (s *Simulation) Play(replay *Replay) error {
+	if err := s.Restore(replay.Initial); err != nil {
+		return err
+	}
+
+	s.SetSeed(replay.Seed)
+	s.recording = nil
+	s.playback = replay.Events
+	s.applyReplayEvents()
+
+	return nil
+}
+
+// Playing checks if there are events from a replay still to be applied.
+func (s *Simulation) Playing() bool {
+	return len(s.playback) > 0
+}
+
+func (s *Simulation) record(event ReplayEvent) {
+	if s.recording == nil {
+		return
+	}
+
+	event.Step = s.steps
+	s.recording.Events = append(s.recording.Events, event)
+}
+
+// recordPhysics records the current physics parameters after one of them was changed.
+func (s *Simulation) recordPhysics() {
+	if s.recording == nil {
+		return
+	}
+
+	p, err := s.snapshotPhysics()
+	if err != nil {
+		s.recordingErr = err
+		return
+	}
+
+	s.record(ReplayEvent{
+		Kind:    ReplayEventKindPhysics,
+		Physics: &p,
+	})
+}
+
+// applyReplayEvents applies every replay event recorded before the current step.
+func (s *Simulation) applyReplayEvents() {
+	if len(s.playback) > 0 && s.playback[0].Step <= s.steps {
+		s.syncEntities()
+	}
+
+	for len(s.playback) > 0 && s.playback[0].Step <= s.steps {
+		event := s.playback[0]
+		s.playback = s.playback[1:]
+
+		switch event.Kind {
+		case ReplayEventKindSpawn:
+			if event.Entity != nil {
+				e := event.Entity.entity()
+				s.register(e)
+				s.appendEntities(e)
+				if s.emitting() {
+					s.emit(CreatedEvent{Step: s.steps, Entity: e, Cause: EventCauseUser})
+				}
+			}
+		case ReplayEventKindVelocity:
+			if e, ok := s.index[event.ID]; ok {
+				e.V = event.V
+				s.loadParticle(e)
+			}
+		case ReplayEventKindUpdate:
+			if event.Entity != nil {
+				if e, ok := s.index[event.Entity.ID]; ok {
+					e.P, e.V, e.R, e.M = event.Entity.P, event.Entity.V, event.Entity.R, event.Entity.M
+					e.Kind = event.Entity.Kind
+					e.Metadata = event.Entity.Metadata.Clone()
+					s.loadParticle(e)
+				}
+			}
+		case ReplayEventKindRemove:
+			for _, id := range event.IDs {
+				e, ok := s.index[id]
+				if !ok {
+					continue
+				}
+
+				e.Disabled = true
+				if s.emitting() {
+					s.emit(RemovedEvent{Step: s.steps, Entity: e, Cause: EventCauseUser})
+				}
+			}
+			s.deleteDisabled()
+		case ReplayEventKindClear:
+			s.clear()
+		case ReplayEventKindPhysics:
+			if event.Physics != nil {
+				if p, err := event.Physics.decode(); err == nil {
+					s.setPhysics(p)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Code generated by go-enum
+// DO NOT EDIT!
+
+package universe
+
+import (
+	"fmt"
+)
+
+const (
+	// ReplayEventKindSpawn is a ReplayEventKind of type Spawn
+	ReplayEventKindSpawn ReplayEventKind = iota
+	// ReplayEventKindVelocity is a ReplayEventKind of type Velocity
+	ReplayEventKindVelocity
+	// ReplayEventKindRemove is a ReplayEventKind of type Remove
+	ReplayEventKindRemove
+	// ReplayEventKindClear is a ReplayEventKind of type Clear
+	ReplayEventKindClear
+	// ReplayEventKindUpdate is a ReplayEventKind of type Update
+	ReplayEventKindUpdate
+	// ReplayEventKindPhysics is a ReplayEventKind of type Physics
+	ReplayEventKindPhysics
+)
+
+const _ReplayEventKindName = "spawnvelocityremoveclearupdatephysics"
+
+var _ReplayEventKindMap = map[ReplayEventKind]string{
+	0: _ReplayEventKindName[0:5],
+	1: _ReplayEventKindName[5:13],
+	2: _ReplayEventKindName[13:19],
+	3: _ReplayEventKindName[19:24],
+	4: _ReplayEventKindName[24:30],
+	5: _ReplayEventKindName[30:37],
+}
+
+// String implements the Stringer interface.
+func (x ReplayEventKind) String() string {
+	if str, ok := _ReplayEventKindMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("ReplayEventKind(%d)", x)
+}
+
+var _ReplayEventKindValue = map[string]ReplayEventKind{
+	_ReplayEventKindName[0:5]:   0,
+	_ReplayEventKindName[5:13]:  1,
+	_ReplayEventKindName[13:19]: 2,
+	_ReplayEventKindName[19:24]: 3,
+	_ReplayEventKindName[24:30]: 4,
+	_ReplayEventKindName[30:37]: 5,
+}
+
+// ParseReplayEventKind attempts to convert a string to a ReplayEventKind
+func ParseReplayEventKind(name string) (ReplayEventKind, error) {
+	if x, ok := _ReplayEventKindValue[name]; ok {
+		return x, nil
+	}
+	return ReplayEventKind(0), fmt.Errorf("%s is not a valid ReplayEventKind", name)
+}
+
+// MarshalText implements the text marshaller method
+func (x ReplayEventKind) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method
+func (x *ReplayEventKind) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseReplayEventKind(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
@@ -0,0 +1,560 @@
+package universe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/relvacode/universe/draw"
+	"github.com/relvacode/universe/internal"
+	"github.com/relvacode/universe/physics"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+)
+
+//go:generate go run github.com/abice/go-enum -f=$GOFILE --marshal
+
+// ScenarioGeneratorKind is the way a scenario generator places bodies.
+/*
+ENUM(
+field,
+disk,
+orbit
+)
+*/
+type ScenarioGeneratorKind uint8
+
+// scenarioPlacementAttempts is the number of times a generator tries to place a body before giving up.
+const scenarioPlacementAttempts = 64
+
+// Scenario is a declarative description of the initial state of a simulation.
+// Positions in a scenario are relative to the centre of the world boundary,
+// so that the same scenario can be used with any world boundary.
+type Scenario struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Seed is the random seed used to generate bodies, the current seed of the simulation is used if zero
+	Seed int64 `json:"seed,omitempty"`
+	// TimeScale is the suggested time scale of a view, 1 is used if zero
+	TimeScale float64         `json:"time_scale,omitempty"`
+	Camera    ScenarioCamera  `json:"camera,omitempty"`
+	Physics   ScenarioPhysics `json:"physics,omitempty"`
+
+	// Bodies are placed in order before any generated bodies
+	Bodies     []ScenarioBody      `json:"bodies,omitempty"`
+	Generators []ScenarioGenerator `json:"generators,omitempty"`
+}
+
+// ScenarioCamera is the initial camera of a scenario.
+type ScenarioCamera struct {
+	// Zoom is the zoom level of the camera, 1 is used if zero
+	Zoom float64 `json:"zoom,omitempty"`
+	// Center is the point the camera is centred on
+	Center internal.Vector `json:"center,omitempty"`
+}
+
+// Camera returns a camera centred on the scenario camera within the given world boundary.
+func (c ScenarioCamera) Camera(world internal.BoundingBox) draw.Camera {
+	zoom := c.Zoom
+	if zoom <= 0 {
+		zoom = 1
+	}
+
+	center := world.Center()
+	return draw.Camera{
+		Zoom: zoom,
+		Offset: internal.Vector{
+			X: center.X + c.Center.X - world.X - world.W/zoom/2,
+			Y: center.Y + c.Center.Y - world.Y - world.H/zoom/2,
+		},
+	}
+}
+
+// ScenarioPhysics contains the physics parameters of a scenario.
+// Parameters that are not given keep their current value in the simulation.
+// Integrators, force laws and collision resolvers are given by their built-in name, as in a snapshot.
+type ScenarioPhysics struct {
+	Theta             *float64      `json:"theta,omitempty"`
+	G                 *float64      `json:"g,omitempty"`
+	Softening         *float64      `json:"softening,omitempty"`
+	ForceLaw          string        `json:"force_law,omitempty"`
+	Integrator        string        `json:"integrator,omitempty"`
+	TimestepMode      *TimestepMode `json:"timestep_mode,omitempty"`
+	TimestepAccuracy  *float64      `json:"timestep_accuracy,omitempty"`
+	MaxTimestepLevel  *int          `json:"max_timestep_level,omitempty"`
+	CollisionResolver string        `json:"collision_resolver,omitempty"`
+
+	// CollisionResolverParameters configure the collision resolver,
+	// or the current collision resolver of the simulation if no collision resolver is given
+	CollisionResolverParameters json.RawMessage `json:"collision_resolver_parameters,omitempty"`
+
+	ContinuousCollisions *bool         `json:"continuous_collisions,omitempty"`
+	BoundaryMode         *BoundaryMode `json:"boundary_mode,omitempty"`
+}
+
+// ScenarioBody is a single body in a scenario.
+type ScenarioBody struct {
+	P internal.Vector `json:"p,omitempty"`
+	V internal.Vector `json:"v,omitempty"`
+	R float64         `json:"r"`
+	// M is the mass of the body, derived from its radius as in NewEntity if zero
+	M        float64   `json:"m,omitempty"`
+	Kind     BodyKind  `json:"kind,omitempty"`
+	Metadata *Metadata `json:"metadata,omitempty"`
+	// Orbit places the body on a circular orbit instead of at P with velocity V
+	Orbit *ScenarioOrbit `json:"orbit,omitempty"`
+}
+
+// ScenarioOrbit is a circular orbit around a body placed earlier in the scenario.
+type ScenarioOrbit struct {
+	// Around is the name in the metadata of the orbited body, every body given earlier is orbited if empty
+	Around   string  `json:"around,omitempty"`
+	Distance float64 `json:"distance"`
+	// Angle is the initial angle of the body around the orbited body in degrees
+	Angle float64 `json:"angle,omitempty"`
+	// Retrograde orbits turn in the opposite direction
+	Retrograde bool `json:"retrograde,omitempty"`
+}
+
+// ScenarioGenerator places a number of randomly distributed bodies.
+//
+// A field places bodies uniformly within a box moving at velocity V.
+// A disk places bodies uniformly within a ring around a centre, moving at velocity V and rotating at the angular velocity Spin.
+// An orbit places bodies uniformly within a ring around a named body, each on a circular orbit around it.
+type ScenarioGenerator struct {
+	Kind  ScenarioGeneratorKind `json:"kind"`
+	Count int                   `json:"count"`
+	// Radius is the range of radii of the generated bodies
+	Radius ScenarioRange `json:"radius"`
+	// Metadata is given to every generated body
+	Metadata *Metadata `json:"metadata,omitempty"`
+
+	// Box is the area of a field, the world boundary is used if empty
+	Box internal.BoundingBox `json:"box,omitempty"`
+	// Center is the centre of a disk
+	Center internal.Vector `json:"center,omitempty"`
+	// V is the velocity of bodies in a field or disk
+	V internal.Vector `json:"v,omitempty"`
+	// Distance is the range of distances from the centre of a disk or orbit
+	Distance ScenarioRange `json:"distance,omitempty"`
+	// Spin is the angular velocity of a disk in radians per second
+	Spin float64 `json:"spin,omitempty"`
+	// Around is the name in the metadata of the body orbited by an orbit, every body placed earlier is orbited if empty
+	Around string `json:"around,omitempty"`
+	// Retrograde orbits turn in the opposite direction
+	Retrograde bool `json:"retrograde,omitempty"`
+}
+
+// ScenarioRange is a range of values, given either as an object with a min and max or as a single number.
+type ScenarioRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+func (r *ScenarioRange) UnmarshalJSON(b []byte) error {
+	var value float64
+	if err := json.Unmarshal(b, &value); err == nil {
+		r.Min, r.Max = value, value
+		return nil
+	}
+
+	type scenarioRange ScenarioRange
+	return json.Unmarshal(b, (*scenarioRange)(r))
+}
+
+// uniform returns a uniformly distributed value in the range.
+func (r ScenarioRange) uniform(rng *rand.Rand) float64 {
+	return r.Min + (r.Max-r.Min)*rng.Float64()
+}
+
+// LoadScenario reads a JSON or YAML scenario from r.
+// Unknown fields are rejected so that mistakes in hand written scenarios are not silently ignored.
+func LoadScenario(r io.Reader) (*Scenario, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is valid YAML, so both are decoded as YAML and then converted to JSON,
+	// which lets scenarios use the JSON encoding of enums, vectors and collision resolver parameters.
+	var document interface{}
+	if err := yaml.Unmarshal(b, &document); err != nil {
+		return nil, err
+	}
+
+	b, err = json.Marshal(jsonDocument(document))
+	if err != nil {
+		return nil, err
+	}
+
+	scenario := new(Scenario)
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(scenario); err != nil {
+		return nil, err
+	}
+
+	return scenario, nil
+}
+
+// jsonDocument converts a decoded YAML document into a value that can be encoded as JSON.
+func jsonDocument(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = jsonDocument(value)
+		}
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = jsonDocument(value)
+		}
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = jsonDocument(value)
+		}
+	}
+
+	return v
+}
+
+// Setup replaces the entities of the simulation with the bodies of the scenario and applies its physics parameters.
+// The simulation is not modified if the scenario is invalid.
+// A scenario cannot be set up while the simulation is being recorded, because a replay cannot reproduce it.
+func (s *Simulation) Setup(scenario *Scenario) error {
+	if s.Recording() {
+		return fmt.Errorf("cannot set up a scenario while recording")
+	}
+
+	p := scenario.Physics
+
+	gravity := s.gravity
+	if p.ForceLaw != "" {
+		law, ok := physics.ForceLawByName(p.ForceLaw)
+		if !ok {
+			return fmt.Errorf("unknown force law %q", p.ForceLaw)
+		}
+		gravity.Law = law
+	}
+	if p.G != nil {
+		gravity.G = *p.G
+	}
+	if p.Softening != nil {
+		gravity.Softening = *p.Softening
+	}
+
+	integrator := s.integrator
+	if p.Integrator != "" {
+		var ok bool
+		integrator, ok = IntegratorByName(p.Integrator)
+		if !ok {
+			return fmt.Errorf("unknown integrator %q", p.Integrator)
+		}
+	}
+
+	resolver := s.collisionResolver
+	if p.CollisionResolver != "" || len(p.CollisionResolverParameters) > 0 {
+		name := p.CollisionResolver
+		if name == "" {
+			var ok bool
+			name, ok = CollisionResolverName(resolver)
+			if !ok {
+				return fmt.Errorf("collision resolver %T cannot be configured", resolver)
+			}
+		}
+
+		var err error
+		resolver, err = decodeCollisionResolver(name, p.CollisionResolverParameters)
+		if err != nil {
+			return err
+		}
+	}
+
+	seed := s.seed
+	if scenario.Seed != 0 {
+		seed = scenario.Seed
+	}
+
+	center := s.worldBoundary.Center()
+	g := &scenarioGenerator{
+		rng:     rand.New(rand.NewSource(seed)),
+		gravity: gravity,
+		world: internal.BoundingBox{
+			X: s.worldBoundary.X - center.X,
+			Y: s.worldBoundary.Y - center.Y,
+			W: s.worldBoundary.W,
+			H: s.worldBoundary.H,
+		},
+		named: make(map[string]*Entity),
+	}
+
+	for i, body := range scenario.Bodies {
+		if err := g.body(body); err != nil {
+			return fmt.Errorf("body %d: %s", i, err)
+		}
+	}
+
+	for i, generator := range scenario.Generators {
+		if err := g.generate(generator); err != nil {
+			return fmt.Errorf("generator %d: %s", i, err)
+		}
+	}
+
+	if p.Theta != nil {
+		s.SetTheta(*p.Theta)
+	}
+	s.SetGravity(gravity)
+	s.SetIntegrator(integrator)
+	if p.TimestepMode != nil {
+		s.SetTimestepMode(*p.TimestepMode)
+	}
+	if p.TimestepAccuracy != nil {
+		s.SetTimestepAccuracy(*p.TimestepAccuracy)
+	}
+	if p.MaxTimestepLevel != nil {
+		s.SetMaxTimestepLevel(*p.MaxTimestepLevel)
+	}
+	s.SetCollisionResolver(resolver)
+	if p.ContinuousCollisions != nil {
+		s.SetContinuousCollisions(*p.ContinuousCollisions)
+	}
+	if p.BoundaryMode != nil {
+		s.SetBoundaryMode(*p.BoundaryMode)
+	}
+	s.SetSeed(seed)
+
+	s.clear()
+	s.nextID = 0
+
+	for _, e := range g.entities {
+		e.P.X += center.X
+		e.P.Y += center.Y
+		s.register(e)
+		s.entities = append(s.entities, e)
+	}
+
+	s.steps = 0
+	s.timeStepRemaining = 0
+	s.playback = nil
+	s.ResetDiagnostics()
+
+	return nil
+}
+
+// scenarioGenerator places the bodies of a scenario relative to the centre of the world boundary.
+type scenarioGenerator struct {
+	rng     *rand.Rand
+	gravity physics.Gravity
+	world   internal.BoundingBox
+
+	entities EntityList
+	// named are placed bodies by the name in their metadata
+	named map[string]*Entity
+}
+
+func (g *scenarioGenerator) add(e *Entity) {
+	g.entities = append(g.entities, e)
+	if e.Metadata != nil && e.Metadata.Name != "" {
+		if _, ok := g.named[e.Metadata.Name]; !ok {
+			g.named[e.Metadata.Name] = e
+		}
+	}
+}
+
+// around returns the body with the given name.
+// If the name is empty the bodies placed so far are orbited as a whole,
+// as a body at their centre of mass that does not recoil.
+func (g *scenarioGenerator) around(name string) (*Entity, error) {
+	if name == "" {
+		return g.barycentre()
+	}
+
+	e, ok := g.named[name]
+	if !ok {
+		return nil, fmt.Errorf("no body named %q", name)
+	}
+	return e, nil
+}
+
+func (g *scenarioGenerator) barycentre() (*Entity, error) {
+	barycentre := &Entity{Kind: BodyKindStatic}
+	for _, e := range g.entities {
+		barycentre.M += e.M
+		barycentre.P.X += e.P.X * e.M
+		barycentre.P.Y += e.P.Y * e.M
+		barycentre.V.X += e.V.X * e.M
+		barycentre.V.Y += e.V.Y * e.M
+	}
+
+	if barycentre.M == 0 {
+		return nil, fmt.Errorf("there are no bodies to orbit")
+	}
+
+	barycentre.P.X /= barycentre.M
+	barycentre.P.Y /= barycentre.M
+	barycentre.V.X /= barycentre.M
+	barycentre.V.Y /= barycentre.M
+	return barycentre, nil
+}
+
+// overlaps checks if e overlaps any placed body.
+func (g *scenarioGenerator) overlaps(e *Entity) bool {
+	for _, o := range g.entities {
+		if _, ok := physics.Colliding(e.Object, o.Object); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// orbit gives e the velocity of a circular orbit around another body.
+// The orbited body recoils so that the momentum of the pair is unchanged, unless it is not dynamic.
+func (g *scenarioGenerator) orbit(e, around *Entity, retrograde bool) {
+	dx, dy := e.P.X-around.P.X, e.P.Y-around.P.Y
+	distance := math.Hypot(dx, dy)
+	if distance == 0 {
+		e.V = around.V
+		return
+	}
+
+	// The distance between two dynamic bodies changes as if a body with their combined mass was orbited
+	m := around.M
+	if around.Dynamic() {
+		m += e.M
+	}
+
+	a := g.gravity.Acceleration(e.P, around.P, m)
+	speed := math.Sqrt(math.Hypot(a.X, a.Y) * distance)
+	if retrograde {
+		speed = -speed
+	}
+
+	relative := internal.Vector{
+		X: -dy / distance * speed,
+		Y: dx / distance * speed,
+	}
+
+	if !around.Dynamic() {
+		e.V = internal.Vector{X: around.V.X + relative.X, Y: around.V.Y + relative.Y}
+		return
+	}
+
+	total := around.M + e.M
+	e.V = internal.Vector{
+		X: around.V.X + relative.X*around.M/total,
+		Y: around.V.Y + relative.Y*around.M/total,
+	}
+	around.V = internal.Vector{
+		X: around.V.X - relative.X*e.M/total,
+		Y: around.V.Y - relative.Y*e.M/total,
+	}
+}
+
+func (g *scenarioGenerator) body(body ScenarioBody) error {
+	if body.R <= 0 {
+		return fmt.Errorf("radius must be positive")
+	}
+
+	e := NewEntity(body.P, body.V, body.R)
+	if body.M > 0 {
+		e.M = body.M
+	}
+	e.Kind = body.Kind
+	e.Metadata = body.Metadata.Clone()
+
+	if body.Orbit != nil {
+		around, err := g.around(body.Orbit.Around)
+		if err != nil {
+			return err
+		}
+
+		angle := body.Orbit.Angle * math.Pi / 180
+		e.P = internal.Vector{
+			X: around.P.X + body.Orbit.Distance*math.Cos(angle),
+			Y: around.P.Y + body.Orbit.Distance*math.Sin(angle),
+		}
+		g.orbit(e, around, body.Orbit.Retrograde)
+	}
+
+	g.add(e)
+	return nil
+}
+
+// ring returns a point uniformly distributed within a ring around center.
+func (g *scenarioGenerator) ring(center internal.Vector, distance ScenarioRange) internal.Vector {
+	angle := g.rng.Float64() * math.Pi * 2
+	d := math.Sqrt(distance.Min*distance.Min + (distance.Max*distance.Max-distance.Min*distance.Min)*g.rng.Float64())
+	return internal.Vector{
+		X: center.X + d*math.Cos(angle),
+		Y: center.Y + d*math.Sin(angle),
+	}
+}
+
+func (g *scenarioGenerator) generate(generator ScenarioGenerator) error {
+	if generator.Count <= 0 {
+		return fmt.Errorf("count must be positive")
+	}
+	if generator.Radius.Min <= 0 || generator.Radius.Max < generator.Radius.Min {
+		return fmt.Errorf("invalid radius range %v", generator.Radius)
+	}
+	if generator.Distance.Min < 0 || generator.Distance.Max < generator.Distance.Min {
+		return fmt.Errorf("invalid distance range %v", generator.Distance)
+	}
+
+	var around *Entity
+	if generator.Kind == ScenarioGeneratorKindOrbit {
+		var err error
+		around, err = g.around(generator.Around)
+		if err != nil {
+			return err
+		}
+	}
+
+	box := generator.Box
+	if box.W <= 0 || box.H <= 0 {
+		box = g.world
+	}
+
+	for i := 0; i < generator.Count; i++ {
+		e := NewEntity(internal.Vector{}, generator.V, generator.Radius.uniform(g.rng))
+		e.Metadata = generator.Metadata.Clone()
+
+		placed := false
+		for attempt := 0; attempt < scenarioPlacementAttempts && !placed; attempt++ {
+			switch generator.Kind {
+			case ScenarioGeneratorKindField:
+				e.P = internal.Vector{
+					X: box.X + box.W*g.rng.Float64(),
+					Y: box.Y + box.H*g.rng.Float64(),
+				}
+			case ScenarioGeneratorKindDisk:
+				e.P = g.ring(generator.Center, generator.Distance)
+			case ScenarioGeneratorKindOrbit:
+				e.P = g.ring(around.P, generator.Distance)
+			default:
+				return fmt.Errorf("unknown generator kind %s", generator.Kind)
+			}
+
+			placed = !g.overlaps(e)
+		}
+
+		if !placed {
+			return fmt.Errorf("could not place body %d without overlapping another body", i)
+		}
+
+		switch generator.Kind {
+		case ScenarioGeneratorKindDisk:
+			e.V.X -= generator.Spin * (e.P.Y - generator.Center.Y)
+			e.V.Y += generator.Spin * (e.P.X - generator.Center.X)
+		case ScenarioGeneratorKindOrbit:
+			g.orbit(e, around, generator.Retrograde)
+		}
+
+		g.add(e)
+	}
+
+	return nil
+}
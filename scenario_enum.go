@@ -0,0 +1,63 @@
+// Code generated by go-enum
+// DO NOT EDIT!
+
+package universe
+
+import (
+	"fmt"
+)
+
+const (
+	// ScenarioGeneratorKindField is a ScenarioGeneratorKind of type Field
+	ScenarioGeneratorKindField ScenarioGeneratorKind = iota
+	// ScenarioGeneratorKindDisk is a ScenarioGeneratorKind of type Disk
+	ScenarioGeneratorKindDisk
+	// ScenarioGeneratorKindOrbit is a ScenarioGeneratorKind of type Orbit
+	ScenarioGeneratorKindOrbit
+)
+
+const _ScenarioGeneratorKindName = "fielddiskorbit"
+
+var _ScenarioGeneratorKindMap = map[ScenarioGeneratorKind]string{
+	0: _ScenarioGeneratorKindName[0:5],
+	1: _ScenarioGeneratorKindName[5:9],
+	2: _ScenarioGeneratorKindName[9:14],
+}
+
+// String implements the Stringer interface.
+func (x ScenarioGeneratorKind) String() string {
+	if str, ok := _ScenarioGeneratorKindMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("ScenarioGeneratorKind(%d)", x)
+}
+
+var _ScenarioGeneratorKindValue = map[string]ScenarioGeneratorKind{
+	_ScenarioGeneratorKindName[0:5]:  0,
+	_ScenarioGeneratorKindName[5:9]:  1,
+	_ScenarioGeneratorKindName[9:14]: 2,
+}
+
+// ParseScenarioGeneratorKind attempts to convert a string to a ScenarioGeneratorKind
+func ParseScenarioGeneratorKind(name string) (ScenarioGeneratorKind, error) {
+	if x, ok := _ScenarioGeneratorKindValue[name]; ok {
+		return x, nil
+	}
+	return ScenarioGeneratorKind(0), fmt.Errorf("%s is not a valid ScenarioGeneratorKind", name)
+}
+
+// MarshalText implements the text marshaller method
+func (x ScenarioGeneratorKind) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method
+func (x *ScenarioGeneratorKind) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseScenarioGeneratorKind(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
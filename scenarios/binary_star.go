@@ -0,0 +1,24 @@
+package scenarios
+
+const binaryStar = `
+name: Binary star
+description: Two equal stars orbiting their common centre of mass inside a circumbinary disk.
+seed: 1
+physics:
+  integrator: leapfrog
+  collision_resolver: absorb
+  boundary_mode: open
+bodies:
+  - p: {x: -100, y: 0}
+    r: 20
+    metadata: {name: Alpha, color: "#9fc3ff"}
+  - r: 20
+    orbit: {around: Alpha, distance: 200, angle: 0}
+    metadata: {name: Beta, color: "#ffb36b"}
+generators:
+  - kind: orbit
+    count: 200
+    radius: {min: 0.5, max: 1.5}
+    distance: {min: 300, max: 450}
+    metadata: {tags: [disk]}
+`
@@ -0,0 +1,34 @@
+package scenarios
+
+const galaxyCollision = `
+name: Galaxy collision
+description: Two disk galaxies with heavy cores passing through each other, one rotating in the opposite direction. Stars pass through each other as they would in real galaxies.
+seed: 1
+physics:
+  integrator: leapfrog
+  collision_resolver: noop
+  boundary_mode: open
+bodies:
+  - p: {x: -400, y: -160}
+    v: {x: 20, y: -20}
+    r: 25
+    metadata: {name: Andromeda, color: "#ffd27f"}
+  - p: {x: 400, y: 160}
+    v: {x: -20, y: 20}
+    r: 25
+    metadata: {name: Milky Way, color: "#ffd27f"}
+generators:
+  - kind: orbit
+    around: Andromeda
+    count: 300
+    radius: {min: 0.5, max: 1.5}
+    distance: {min: 40, max: 170}
+    metadata: {tags: [star], color: "#9fc3ff"}
+  - kind: orbit
+    around: Milky Way
+    retrograde: true
+    count: 300
+    radius: {min: 0.5, max: 1.5}
+    distance: {min: 40, max: 170}
+    metadata: {tags: [star], color: "#ff9f9f"}
+`
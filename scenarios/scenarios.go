@@ -0,0 +1,46 @@
+// Package scenarios contains the example scenarios bundled with universe.
+package scenarios
+
+import (
+	"fmt"
+	"github.com/relvacode/universe"
+	"strings"
+)
+
+// Bundled is an example scenario in the YAML scenario format.
+type Bundled struct {
+	Name   string
+	Source string
+}
+
+// All is every bundled scenario in the order they are presented.
+var All = []Bundled{
+	{Name: "solar-system", Source: solarSystem},
+	{Name: "binary-star", Source: binaryStar},
+	{Name: "galaxy-collision", Source: galaxyCollision},
+}
+
+// Names returns the name of every bundled scenario.
+func Names() []string {
+	names := make([]string, len(All))
+	for i, b := range All {
+		names[i] = b.Name
+	}
+	return names
+}
+
+// Load parses the bundled scenario with the given name.
+func Load(name string) (*universe.Scenario, error) {
+	for _, b := range All {
+		if b.Name == name {
+			return b.Load()
+		}
+	}
+
+	return nil, fmt.Errorf("unknown scenario %q, expected one of %s", name, strings.Join(Names(), ", "))
+}
+
+// Load parses the bundled scenario.
+func (b Bundled) Load() (*universe.Scenario, error) {
+	return universe.LoadScenario(strings.NewReader(b.Source))
+}
@@ -0,0 +1,51 @@
+package scenarios
+
+const solarSystem = `
+name: Solar system
+description: A fixed sun orbited by eight planets and an asteroid belt.
+seed: 1
+physics:
+  integrator: leapfrog
+  collision_resolver: absorb
+  boundary_mode: open
+bodies:
+  - r: 30
+    m: 100000
+    kind: static
+    metadata: {name: Sun, color: "#ffcc33"}
+  - r: 3
+    orbit: {around: Sun, distance: 70, angle: 0}
+    metadata: {name: Mercury, color: "#b0a89e"}
+  - r: 5
+    orbit: {around: Sun, distance: 110, angle: 135}
+    metadata: {name: Venus, color: "#e8c27a"}
+  - r: 6
+    orbit: {around: Sun, distance: 155, angle: 240}
+    metadata: {name: Earth, color: "#3d8bff"}
+  - r: 4
+    orbit: {around: Sun, distance: 200, angle: 60}
+    metadata: {name: Mars, color: "#e0603a"}
+  - r: 14
+    m: 120
+    orbit: {around: Sun, distance: 330, angle: 180}
+    metadata: {name: Jupiter, color: "#d9a066"}
+  - r: 11
+    m: 90
+    orbit: {around: Sun, distance: 400, angle: 300}
+    metadata: {name: Saturn, color: "#e6cf8f"}
+  - r: 8
+    m: 60
+    orbit: {around: Sun, distance: 460, angle: 30}
+    metadata: {name: Uranus, color: "#9fe3e8"}
+  - r: 8
+    m: 60
+    orbit: {around: Sun, distance: 515, angle: 210}
+    metadata: {name: Neptune, color: "#4f6fe8"}
+generators:
+  - kind: orbit
+    around: Sun
+    count: 150
+    radius: {min: 0.5, max: 1.5}
+    distance: {min: 240, max: 290}
+    metadata: {tags: [asteroid], color: "#8a8174"}
+`
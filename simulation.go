@@ -4,8 +4,26 @@ import (
 	"github.com/relvacode/universe/internal"
 	"github.com/relvacode/universe/physics"
 	"math"
+	"math/rand"
+	"time"
 )
 
+const PhysicsConstantTimestep = 0.0165 // 60FPS
+
+// DefaultTheta is the default Barnes-Hut opening angle.
+const DefaultTheta = 0.5
+
+// DefaultSeed is the seed of the random source of a new simulation.
+const DefaultSeed = 1
+
+const (
+	treeMaxEntries = 28
+
+	// treeMinDepth and treeMaxDepth bound the maximum depth of a tree,
+	// which is derived from the size of its root and the smallest entity within it.
+	treeMinDepth = 8
+	treeMaxDepth = 32
+)
 
 type entityCollisionMap map[[2]*Entity]struct{}
 
@@ -18,21 +36,453 @@ func (m entityCollisionMap) store(e1, e2 *Entity) {
 	m[[2]*Entity{e2, e1}] = struct{}{}
 }
 
+// SimulationStats contains timing information about the most recent physics step.
+type SimulationStats struct {
+	Substeps     int
+	Interactions time.Duration
+	Collisions   time.Duration
+	// ResolvedCollisions is the number of collisions passed to the collision resolver.
+	ResolvedCollisions int
+}
+
 func NewSimulation(worldBoundary internal.BoundingBox) *Simulation {
 	return &Simulation{
-		worldBoundary: worldBoundary,
+		seed:              DefaultSeed,
+		rng:               rand.New(rand.NewSource(DefaultSeed)),
+		worldBoundary:     worldBoundary,
+		collisionResolver: NoopCollisionResolver{},
+		theta:             DefaultTheta,
+		gravity:           physics.DefaultGravity,
+		integrator:        EulerIntegrator{},
+		timestepAccuracy:  DefaultTimestepAccuracy,
+		maxTimestepLevel:  DefaultMaxTimestepLevel,
 	}
 }
 
+// Simulation drives the physics of a set of entities independently of any rendering.
 type Simulation struct {
 	tree          *QuadTree
 	worldBoundary internal.BoundingBox
+
+	entities          EntityList
+	index             map[EntityID]*Entity
+	nextID            EntityID
+	collisionResolver CollisionResolver
+	theta             float64
+	gravity           physics.Gravity
+	integrator        Integrator
+	workers           int
+
+	timestepMode     TimestepMode
+	timestepAccuracy float64
+	maxTimestepLevel int
+
+	boundaryMode BoundaryMode
+
+	continuousCollisions bool
+	sweepPosition        []internal.Vector
+	sweepVelocity        []internal.Vector
+	sweepTime            []float64
+	sweepMoved           []bool
+	sweepBounds          []internal.BoundingBox
+	sweepOrder           []int
+	sweepEvents          []sweepEvent
+
+	compiled   EntityList
+	active     EntityList
+	potentials []float64
+	// created are the entities created by collisions within the current block timestep
+	created EntityList
+
+	particles    *ParticleStore
+	particleTree ParticleTree
+	// particlesCurrent is set while the particle store holds the state of every entity
+	particlesCurrent bool
+	// entitiesStale is set when particles have moved since their state was last copied to the entities
+	entitiesStale bool
+
+	trackDiagnostics    bool
+	diagnostics         Diagnostics
+	diagnosticsBaseline Diagnostics
+
+	seed          int64
+	rng           *rand.Rand
+	deterministic bool
+
+	recording    *Replay
+	recordingErr error
+	playback     []ReplayEvent
+
+	subscribers    []subscriber
+	nextSubscriber int
+
+	timeStepRemaining float64
+	steps             int
+
+	stats SimulationStats
+}
+
+// Entities returns the list of entities in the simulation.
+// The list may be modified between steps.
+// Entities appended to the list are given an ID at the start of the next step,
+// entities should be removed using Remove so that they are also removed from the ID index.
+func (s *Simulation) Entities() *EntityList {
+	s.releaseParticles()
+	return &s.entities
+}
+
+// Len returns the number of entities in the simulation.
+func (s *Simulation) Len() int {
+	return len(s.entities)
+}
+
+// Add adds entities to the simulation.
+// Each entity is given an ID, an entity keeps its existing ID if it is not already used by another entity.
+func (s *Simulation) Add(entities ...*Entity) {
+	for _, e := range entities {
+		s.register(e)
+
+		se := snapshotEntity(e)
+		s.record(ReplayEvent{
+			Kind:   ReplayEventKindSpawn,
+			Entity: &se,
+		})
+	}
+
+	s.appendEntities(entities...)
+
+	if s.emitting() {
+		for _, e := range entities {
+			s.emit(CreatedEvent{Step: s.steps, Entity: e, Cause: EventCauseUser})
+		}
+	}
+}
+
+// SetVelocity changes the velocity of an entity in the simulation.
+func (s *Simulation) SetVelocity(e *Entity, v internal.Vector) {
+	s.syncEntities()
+
+	s.record(ReplayEvent{
+		Kind: ReplayEventKindVelocity,
+		ID:   e.ID,
+		V:    v,
+	})
+
+	e.V = v
+	s.loadParticle(e)
+}
+
+// Remove removes every entity for which f returns true.
+// Returns the number of entities removed.
+func (s *Simulation) Remove(f func(e *Entity) bool) int {
+	s.syncEntities()
+
+	var removed []EntityID
+	for _, e := range s.entities {
+		if f(e) {
+			removed = append(removed, e.ID)
+			e.Disabled = true
+			if s.emitting() {
+				s.emit(RemovedEvent{Step: s.steps, Entity: e, Cause: EventCauseUser})
+			}
+		}
+	}
+
+	if len(removed) == 0 {
+		return 0
+	}
+
+	s.record(ReplayEvent{
+		Kind: ReplayEventKindRemove,
+		IDs:  removed,
+	})
+
+	return s.deleteDisabled()
+}
+
+// Clear removes all entities from the simulation.
+func (s *Simulation) Clear() {
+	s.record(ReplayEvent{
+		Kind: ReplayEventKindClear,
+	})
+
+	s.clear()
+}
+
+// clear removes all entities from the simulation without recording a replay event.
+func (s *Simulation) clear() {
+	s.releaseParticles()
+
+	if s.emitting() {
+		for _, e := range s.entities {
+			s.emit(RemovedEvent{Step: s.steps, Entity: e, Cause: EventCauseUser})
+		}
+	}
+
+	s.entities.Clear()
+	s.index = nil
+}
+
+// Rand returns the random source of the simulation.
+// Placing entities using this source makes the result reproducible from the seed.
+func (s *Simulation) Rand() *rand.Rand {
+	return s.rng
+}
+
+func (s *Simulation) Seed() int64 {
+	return s.seed
+}
+
+// SetSeed resets the random source of the simulation with a new seed.
+func (s *Simulation) SetSeed(seed int64) {
+	s.seed = seed
+	s.rng = rand.New(rand.NewSource(seed))
+}
+
+func (s *Simulation) Deterministic() bool {
+	return s.deterministic
+}
+
+// SetDeterministic enables or disables deterministic mode.
+// Each step only depends on the state of the simulation,
+// but the number of steps run by Advance depends on the measured frame time.
+// In deterministic mode a renderer advances the simulation by exactly one PhysicsConstantTimestep for every frame,
+// so that a session does not depend on the speed of the machine running it.
+func (s *Simulation) SetDeterministic(enabled bool) {
+	s.deterministic = enabled
+}
+
+func (s *Simulation) CollisionResolver() CollisionResolver {
+	return s.collisionResolver
+}
+
+func (s *Simulation) SetCollisionResolver(resolver CollisionResolver) {
+	if resolver == nil {
+		resolver = NoopCollisionResolver{}
+	}
+	s.collisionResolver = resolver
+	s.recordPhysics()
+}
+
+// Theta returns the Barnes-Hut opening angle.
+func (s *Simulation) Theta() float64 {
+	return s.theta
+}
+
+// SetTheta sets the Barnes-Hut opening angle used to approximate gravity.
+// Smaller values are more accurate but slower, a value of 0 calculates the exact attraction between every pair of entities.
+func (s *Simulation) SetTheta(theta float64) {
+	if theta < 0 {
+		theta = 0
+	}
+	s.theta = theta
+	s.recordPhysics()
+}
+
+// Gravity returns the force law, gravitational constant and softening length used by the simulation.
+func (s *Simulation) Gravity() physics.Gravity {
+	return s.gravity
+}
+
+func (s *Simulation) SetGravity(g physics.Gravity) {
+	if g.Law == nil {
+		g.Law = physics.DefaultGravity.Law
+	}
+	s.gravity = g
+	s.recordPhysics()
+}
+
+func (s *Simulation) Integrator() Integrator {
+	return s.integrator
+}
+
+// SetIntegrator sets the numerical integrator used to advance entities on each step.
+func (s *Simulation) SetIntegrator(integrator Integrator) {
+	if integrator == nil {
+		integrator = EulerIntegrator{}
+	}
+	s.integrator = integrator
+	s.recordPhysics()
+}
+
+// SetWorkers sets the number of goroutines used to calculate gravity.
+// A value of zero uses one goroutine for each available CPU.
+// Any user supplied force law must be safe to call concurrently.
+func (s *Simulation) SetWorkers(n int) {
+	if n < 0 {
+		n = 0
+	}
+	s.workers = n
+}
+
+// SetParticleStorage enables or disables stepping the simulation on a ParticleStore.
+// While enabled the particle store is the state of the simulation: integration, building the tree, gravity,
+// collision detection and the boundary all operate on its arrays, and entities are only updated from their particles
+// when they are read through the simulation, such as by Entities, EntityByID, Snapshot or a collision resolver.
+// Accessing the entity list with Entities makes the entities the state of the simulation until the next step.
+//
+// Particle storage is used for fixed timesteps of built-in integrators without continuous collision detection,
+// other steps are run on the entities.
+func (s *Simulation) SetParticleStorage(enabled bool) {
+	if !enabled {
+		if s.particles != nil {
+			s.releaseParticles()
+		}
+		s.particles = nil
+		return
+	}
+
+	if s.particles == nil {
+		s.particles = NewParticleStore(len(s.entities))
+	}
+}
+
+func (s *Simulation) TimestepMode() TimestepMode {
+	return s.timestepMode
+}
+
+// SetTimestepMode sets how each step is subdivided into smaller timesteps.
+func (s *Simulation) SetTimestepMode(mode TimestepMode) {
+	s.timestepMode = mode
+	s.recordPhysics()
+}
+
+// SetTimestepAccuracy sets the fraction of its radius an entity may move within a single adaptive or block timestep.
+// Smaller values are more accurate but require more sub-steps.
+func (s *Simulation) SetTimestepAccuracy(accuracy float64) {
+	if accuracy <= 0 {
+		accuracy = DefaultTimestepAccuracy
+	}
+	s.timestepAccuracy = accuracy
+	s.recordPhysics()
+}
+
+// SetMaxTimestepLevel sets the maximum number of times a step may be halved in adaptive or block timestep modes.
+func (s *Simulation) SetMaxTimestepLevel(level int) {
+	if level < 0 {
+		level = 0
+	}
+	s.maxTimestepLevel = level
+	s.recordPhysics()
 }
 
-func (s *Simulation) ResolveCollisions(entities []*Entity, resolver CollisionResolver) {
+func (s *Simulation) WorldBoundary() internal.BoundingBox {
+	return s.worldBoundary
+}
+
+func (s *Simulation) SetWorldBoundary(box internal.BoundingBox) {
+	s.worldBoundary = box
+}
+
+// Steps returns the number of physics steps run since the simulation was created.
+func (s *Simulation) Steps() int {
+	return s.steps
+}
+
+// Time returns the total simulated time in seconds.
+func (s *Simulation) Time() float64 {
+	return float64(s.steps) * PhysicsConstantTimestep
+}
+
+func (s *Simulation) Stats() SimulationStats {
+	return s.stats
+}
+
+// Step runs n physics steps of PhysicsConstantTimestep.
+func (s *Simulation) Step(n int) {
+	for i := 0; i < n; i++ {
+		s.runConstantTimeStep(PhysicsConstantTimestep)
+	}
+}
+
+// Advance runs the physics for as long as at least one full step can run within the given number of seconds.
+// Any remaining time is deferred to the next call to Advance.
+// Returns the number of steps that were run.
+func (s *Simulation) Advance(seconds float64) int {
+	var steps int
+	timeScale := seconds + s.timeStepRemaining
+	for ; timeScale >= PhysicsConstantTimestep; timeScale -= PhysicsConstantTimestep {
+		s.runConstantTimeStep(PhysicsConstantTimestep)
+		steps++
+	}
+
+	s.timeStepRemaining = timeScale
+	return steps
+}
+
+// compile compiles the tree from a copy of the entity list because compiling filters the list,
+// and integrators depend on the order of entities remaining stable during a step.
+func (s *Simulation) compile() EntityList {
+	s.compiled = append(s.compiled[:0], s.entities...)
+	return s.CompileTree(s.compiled)
+}
+
+func (s *Simulation) accelerate() {
+	s.compile()
+
+	perf := time.Now()
+	s.Accelerate(s.compiled)
+	s.stats.Interactions += time.Now().Sub(perf)
+}
+
+func (s *Simulation) runConstantTimeStep(timestep float64) {
+	s.applyReplayEvents()
+
+	s.registerAll()
+
+	s.steps++
+	s.stats = SimulationStats{}
+	if len(s.entities) == 0 {
+		return
+	}
+
+	if integrator, ok := s.particleIntegrator(); ok {
+		s.integrateParticles(integrator, timestep)
+	} else {
+		s.releaseParticles()
+		s.prepareBodies(timestep)
+
+		switch s.timestepMode {
+		case AdaptiveTimestep:
+			s.runAdaptiveTimeStep(timestep)
+		case BlockTimestep:
+			s.runBlockTimeStep(timestep)
+		default:
+			s.integrate(timestep)
+		}
+	}
+
+	if s.trackDiagnostics {
+		s.updateDiagnostics()
+	}
+}
+
+// integrate advances every entity by the timestep using the simulation's integrator and then resolves collisions.
+func (s *Simulation) integrate(timestep float64) {
+	s.stats.Substeps++
+	s.beginSweep()
+	s.integrator.Integrate(s.entities, timestep, s.accelerate)
+	s.sweep(timestep)
+	s.applyBoundary()
+
+	s.collide(s.compile())
+}
+
+func (s *Simulation) collide(entities EntityList) {
+	perf := time.Now()
+	s.ResolveCollisions(entities, s.collisionResolver)
+	s.stats.Collisions += time.Now().Sub(perf)
+
+	s.deleteDisabled()
+}
+
+// ResolveCollisions resolves every collision between the entities and any other entity in the tree.
+// Returns the number of collisions passed to the resolver.
+func (s *Simulation) ResolveCollisions(entities []*Entity, resolver CollisionResolver) int {
 	var collisionMap = make(entityCollisionMap)
 
 	var collisions int
+	var created EntityList
 	for _, o := range entities {
 		if o.Disabled {
 			continue
@@ -52,103 +502,77 @@ func (s *Simulation) ResolveCollisions(entities []*Entity, resolver CollisionRes
 
 			collisions++
 
-			resolver(o, m, distance)
+			created = append(created, s.resolve(resolver, o, m, distance)...)
 			return !o.Disabled
 		})
 	}
-}
-
-func (s *Simulation) Interact(timestep float64, invisible EntityList) {
-	var leaves = s.tree.AppendLeaves(nil)
-
-	for il := 0; il < len(leaves); il++ {
-		l1 := leaves[il]
-
-		for _, e1 := range invisible {
-			if e1.Disabled {
-				continue
-			}
-			coM := l1.CenterOfMass()
 
-			impulse := physics.AttractionForceImpulse(e1.P, coM, e1.M, l1.totalMass, timestep)
-			e1.V.X += impulse.X / e1.M
-			e1.V.Y += impulse.Y / e1.M
+	// Entities created by the resolver take part in the next step
+	s.appendCreated(created)
+	return collisions
+}
 
-			impulseLeafShare := internal.Vector{
-				X: -impulse.X / l1.totalMass,
-				Y: -impulse.Y / l1.totalMass,
-			}
+// appendCreated adds entities created by a collision resolver to the simulation.
+// Within a block timestep they are also collected so that they can be given a level before they are kicked.
+func (s *Simulation) appendCreated(created EntityList) {
+	s.appendEntities(created...)
+	if s.timestepMode == BlockTimestep {
+		s.created = append(s.created, created...)
+	}
+}
 
-			for i := 0; i < len(l1.objects); i++ {
-				e2 := l1.objects[i]
-				eMassRatio := e2.M / l1.totalMass
-				e2.V.X += impulseLeafShare.X * eMassRatio
-				e2.V.Y += impulseLeafShare.Y * eMassRatio
-			}
+// appendEntities adds entities to the entity list, and to the particle store while it holds the state of the simulation.
+func (s *Simulation) appendEntities(entities ...*Entity) {
+	s.entities = append(s.entities, entities...)
+	if s.particlesCurrent {
+		for _, e := range entities {
+			s.particles.Append(e)
 		}
+	}
+}
 
-		for i := 0; i < len(l1.objects); i++ {
-			e1 := l1.objects[i]
-			if e1.Disabled {
+// Accelerate calculates the gravitational acceleration acting on each target entity from every entity in the tree
+// using the Barnes-Hut approximation.
+// When the simulation wraps around its boundary the tree also attracts each entity from its periodic images.
+func (s *Simulation) Accelerate(targets EntityList) {
+	offsets := s.imageOffsets()
+
+	// Each entity only accumulates its own acceleration so targets can be split between workers without synchronisation
+	parallel(s.workers, len(targets), func(start, end int) {
+		for _, e1 := range targets[start:end] {
+			if e1.Disabled || !e1.Dynamic() {
 				continue
 			}
 
-			for j := 0; j < i; j++ {
-				e2 := l1.objects[j]
-				if e2.Disabled {
-					continue
-				}
-
-				impulse := physics.AttractionForceImpulse(e1.P, e2.P, e1.M, e2.M, timestep)
-
-				e1.V.X += impulse.X / e1.M
-				e1.V.Y += impulse.Y / e1.M
-
-				e2.V.X += -impulse.X / e2.M
-				e2.V.Y += -impulse.Y / e2.M
+			var a internal.Vector
+			for _, offset := range offsets {
+				f := s.tree.AccelerationAt(e1.P.Add(offset.X, offset.Y), e1, s.theta, s.gravity)
+				a.X += f.X
+				a.Y += f.Y
 			}
 
-			for jl := 0; jl < len(leaves); jl++ {
-				if il == jl {
-					continue
-				}
-
-				l2 := leaves[jl]
-
-				impulse := physics.AttractionForceImpulse(e1.P, l2.CenterOfMass(), e1.M, l2.totalMass, timestep)
-				e1.V.X += impulse.X / e1.M
-				e1.V.Y += impulse.Y / e1.M
-			}
+			e1.A = a
 		}
-	}
+	})
 }
 
-func (s *Simulation) CompileTree(entities EntityList) (EntityList, EntityList) {
-	s.tree = NewQuadTree(s.worldBoundary, 28, 8)
-
-	// Perform a visibility sort.
-	// Entities that are not in the current view are shuffled to the end until all entities are sorted.
-	// The resulting visible cursor is the number of entities that are still visible.
-	var visible int
-	var curE = len(entities)
-	for ; visible < curE; {
-		e := entities[visible]
-
-		ok := s.tree.boundary.Intersects(e.BoundingBox()) && s.tree.Insert(e)
-
-		// Everything ok, the entity at the current position is inside the view
-		// Go to the next entity
-		if ok {
-			visible++
+// CompileTree builds the tree from every live entity in the list.
+// The root of the tree encloses every live entity so that entities anywhere in the world
+// are attracted and collide with the same accuracy.
+// Disabled entities and entities without a finite position are removed from the list, which is filtered in place.
+func (s *Simulation) CompileTree(entities EntityList) EntityList {
+	boundary, depth := treeBoundary(entities)
+	s.tree = NewQuadTree(boundary, treeMaxEntries, depth)
+
+	var n int
+	for _, e := range entities {
+		if e.Disabled || !s.tree.Insert(e) {
 			continue
 		}
 
-		// The entity is not inside the view
-		// Swap the current index with an entity at the current end pointer
-		// And try that one in the next iteration
-		entities[visible], entities[curE-1] = entities[curE-1], entities[visible]
-		curE--
+		entities[n] = e
+		n++
 	}
 
-	return entities[:visible], entities[visible:]
+	return entities[:n]
 }
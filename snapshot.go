@@ -0,0 +1,607 @@
+package universe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/relvacode/universe/draw"
+	"github.com/relvacode/universe/internal"
+	"github.com/relvacode/universe/physics"
+	"io"
+	"io/ioutil"
+	"reflect"
+)
+
+//go:generate go run github.com/abice/go-enum -f=$GOFILE --marshal
+
+// SnapshotFormat is the encoding of a saved snapshot.
+/*
+ENUM(
+json,
+binary
+)
+*/
+type SnapshotFormat uint8
+
+// SnapshotVersion is the version of snapshots created by this package.
+const SnapshotVersion = 1
+
+// snapshotMagic identifies the binary snapshot encoding.
+var snapshotMagic = [4]byte{'U', 'N', 'I', 'V'}
+
+// Snapshot is a complete description of a simulation that can be saved and loaded later.
+type Snapshot struct {
+	Version   int              `json:"version"`
+	Steps     int              `json:"steps"`
+	TimeScale float64          `json:"time_scale"`
+	Camera    draw.Camera      `json:"camera"`
+	Physics   SnapshotPhysics  `json:"physics"`
+	Entities  []SnapshotEntity `json:"entities"`
+	// NextID is the most recent ID given to an entity, so that restored simulations do not reuse the ID of a removed entity
+	NextID EntityID `json:"next_id,omitempty"`
+}
+
+// SnapshotPhysics contains the physics parameters of a simulation.
+// Integrators, force laws and collision resolvers are stored by their built-in name.
+// The parameters of a collision resolver are stored as its JSON encoding.
+type SnapshotPhysics struct {
+	Theta             float64      `json:"theta"`
+	G                 float64      `json:"g"`
+	Softening         float64      `json:"softening"`
+	ForceLaw          string       `json:"force_law"`
+	Integrator        string       `json:"integrator"`
+	TimestepMode      TimestepMode `json:"timestep_mode"`
+	TimestepAccuracy  float64      `json:"timestep_accuracy"`
+	MaxTimestepLevel  int          `json:"max_timestep_level"`
+	CollisionResolver string       `json:"collision_resolver"`
+
+	CollisionResolverParameters json.RawMessage `json:"collision_resolver_parameters,omitempty"`
+
+	ContinuousCollisions bool `json:"continuous_collisions,omitempty"`
+
+	BoundaryMode BoundaryMode `json:"boundary_mode,omitempty"`
+}
+
+type SnapshotEntity struct {
+	ID       EntityID        `json:"id,omitempty"`
+	P        internal.Vector `json:"p"`
+	V        internal.Vector `json:"v"`
+	A        internal.Vector `json:"a"`
+	R        float64         `json:"r"`
+	M        float64         `json:"m"`
+	Disabled bool            `json:"disabled,omitempty"`
+	Kind     BodyKind        `json:"kind,omitempty"`
+	Metadata *Metadata       `json:"metadata,omitempty"`
+}
+
+func snapshotEntity(e *Entity) SnapshotEntity {
+	return SnapshotEntity{
+		ID:       e.ID,
+		P:        e.P,
+		V:        e.V,
+		A:        e.A,
+		R:        e.R,
+		M:        e.M,
+		Disabled: e.Disabled,
+		Kind:     e.Kind,
+		Metadata: e.Metadata.Clone(),
+	}
+}
+
+func (se SnapshotEntity) entity() *Entity {
+	return &Entity{
+		Object: physics.Object{
+			P: se.P,
+			V: se.V,
+			A: se.A,
+			R: se.R,
+			M: se.M,
+		},
+		ID:       se.ID,
+		Disabled: se.Disabled,
+		Kind:     se.Kind,
+		Metadata: se.Metadata.Clone(),
+	}
+}
+
+// snapshotPhysics returns the physics parameters of the simulation.
+// An error is returned if the simulation uses an integrator, force law or collision resolver that is not built-in.
+func (s *Simulation) snapshotPhysics() (SnapshotPhysics, error) {
+	forceLaw, ok := physics.ForceLawName(s.gravity.Law)
+	if !ok {
+		return SnapshotPhysics{}, fmt.Errorf("force law %T cannot be saved", s.gravity.Law)
+	}
+
+	integrator, ok := IntegratorName(s.integrator)
+	if !ok {
+		return SnapshotPhysics{}, fmt.Errorf("integrator %T cannot be saved", s.integrator)
+	}
+
+	resolver, ok := CollisionResolverName(s.collisionResolver)
+	if !ok {
+		return SnapshotPhysics{}, fmt.Errorf("collision resolver %T cannot be saved", s.collisionResolver)
+	}
+
+	resolverParameters, err := json.Marshal(s.collisionResolver)
+	if err != nil {
+		return SnapshotPhysics{}, err
+	}
+	if bytes.Equal(resolverParameters, []byte("{}")) {
+		resolverParameters = nil
+	}
+
+	return SnapshotPhysics{
+		Theta:             s.theta,
+		G:                 s.gravity.G,
+		Softening:         s.gravity.Softening,
+		ForceLaw:          forceLaw,
+		Integrator:        integrator,
+		TimestepMode:      s.timestepMode,
+		TimestepAccuracy:  s.timestepAccuracy,
+		MaxTimestepLevel:  s.maxTimestepLevel,
+		CollisionResolver: resolver,
+
+		CollisionResolverParameters: resolverParameters,
+
+		ContinuousCollisions: s.continuousCollisions,
+
+		BoundaryMode: s.boundaryMode,
+	}, nil
+}
+
+// Snapshot captures the current state of the simulation.
+// The time scale and camera are not part of the simulation and default to a time scale of 1 with no zoom.
+// An error is returned if the simulation uses an integrator, force law or collision resolver that is not built-in.
+func (s *Simulation) Snapshot() (*Snapshot, error) {
+	s.registerAll()
+	s.syncEntities()
+
+	p, err := s.snapshotPhysics()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		Version:   SnapshotVersion,
+		Steps:     s.steps,
+		TimeScale: 1,
+		Camera: draw.Camera{
+			Zoom: 1,
+		},
+		Physics:  p,
+		Entities: make([]SnapshotEntity, len(s.entities)),
+		NextID:   s.nextID,
+	}
+
+	for i, e := range s.entities {
+		snapshot.Entities[i] = snapshotEntity(e)
+	}
+
+	return snapshot, nil
+}
+
+// Restore replaces the entities and physics parameters of the simulation with those in the snapshot.
+// The simulation is not modified if the snapshot is invalid.
+// A snapshot cannot be restored while the simulation is being recorded, because a replay cannot reproduce it.
+func (s *Simulation) Restore(snapshot *Snapshot) error {
+	if s.Recording() {
+		return fmt.Errorf("cannot restore a snapshot while recording")
+	}
+
+	if snapshot.Version != SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snapshot.Version)
+	}
+
+	p, err := snapshot.Physics.decode()
+	if err != nil {
+		return err
+	}
+
+	s.setPhysics(p)
+	s.clear()
+	s.nextID = snapshot.NextID
+
+	// Entities keep their ID so that they can be referenced across snapshots and replays
+	for _, se := range snapshot.Entities {
+		e := se.entity()
+		s.register(e)
+		s.entities = append(s.entities, e)
+	}
+
+	s.steps = snapshot.Steps
+	s.timeStepRemaining = 0
+	s.playback = nil
+	s.ResetDiagnostics()
+
+	return nil
+}
+
+// decodedPhysics are physics parameters with their integrator, force law and collision resolver resolved from their names.
+type decodedPhysics struct {
+	SnapshotPhysics
+	forceLaw   physics.ForceLaw
+	integrator Integrator
+	resolver   CollisionResolver
+}
+
+// decode resolves the built-in integrator, force law and collision resolver named by the physics parameters.
+func (p SnapshotPhysics) decode() (decodedPhysics, error) {
+	forceLaw, ok := physics.ForceLawByName(p.ForceLaw)
+	if !ok {
+		return decodedPhysics{}, fmt.Errorf("unknown force law %q", p.ForceLaw)
+	}
+
+	integrator, ok := IntegratorByName(p.Integrator)
+	if !ok {
+		return decodedPhysics{}, fmt.Errorf("unknown integrator %q", p.Integrator)
+	}
+
+	resolver, err := decodeCollisionResolver(p.CollisionResolver, p.CollisionResolverParameters)
+	if err != nil {
+		return decodedPhysics{}, err
+	}
+
+	return decodedPhysics{
+		SnapshotPhysics: p,
+		forceLaw:        forceLaw,
+		integrator:      integrator,
+		resolver:        resolver,
+	}, nil
+}
+
+// setPhysics replaces the physics parameters of the simulation.
+func (s *Simulation) setPhysics(p decodedPhysics) {
+	s.SetTheta(p.Theta)
+	s.SetGravity(physics.Gravity{
+		Law:       p.forceLaw,
+		G:         p.G,
+		Softening: p.Softening,
+	})
+	s.SetIntegrator(p.integrator)
+	s.SetTimestepMode(p.TimestepMode)
+	s.SetTimestepAccuracy(p.TimestepAccuracy)
+	s.SetMaxTimestepLevel(p.MaxTimestepLevel)
+	s.SetCollisionResolver(p.resolver)
+	s.SetContinuousCollisions(p.ContinuousCollisions)
+	s.SetBoundaryMode(p.BoundaryMode)
+}
+
+// decodeCollisionResolver returns the built-in collision resolver with the given name,
+// configured by the JSON encoding of its parameters if there are any.
+func decodeCollisionResolver(name string, parameters json.RawMessage) (CollisionResolver, error) {
+	resolver, ok := CollisionResolverByName(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown collision resolver %q", name)
+	}
+
+	if len(parameters) == 0 {
+		return resolver, nil
+	}
+
+	// Decode the parameters into a copy of the default resolver
+	decoded := reflect.New(reflect.TypeOf(resolver))
+	decoded.Elem().Set(reflect.ValueOf(resolver))
+	if err := json.Unmarshal(parameters, decoded.Interface()); err != nil {
+		return nil, fmt.Errorf("invalid parameters for collision resolver %q: %s", name, err)
+	}
+
+	return decoded.Elem().Interface().(CollisionResolver), nil
+}
+
+// Save writes the snapshot to w in the given format.
+func Save(w io.Writer, snapshot *Snapshot, format SnapshotFormat) error {
+	switch format {
+	case SnapshotFormatJson:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snapshot)
+	case SnapshotFormatBinary:
+		return saveBinary(w, snapshot)
+	}
+
+	return fmt.Errorf("unsupported snapshot format %s", format)
+}
+
+// Load reads a snapshot from r.
+// The format of the snapshot is detected automatically.
+func Load(r io.Reader) (*Snapshot, error) {
+	br := bufio.NewReader(r)
+
+	var snapshot *Snapshot
+	var err error
+
+	magic, _ := br.Peek(len(snapshotMagic))
+	if bytes.Equal(magic, snapshotMagic[:]) {
+		snapshot, err = loadBinary(br)
+	} else {
+		snapshot = new(Snapshot)
+		err = json.NewDecoder(br).Decode(snapshot)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if snapshot.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", snapshot.Version)
+	}
+
+	return snapshot, nil
+}
+
+// binarySnapshotHeader is the fixed size part of the binary snapshot encoding after the magic and version.
+type binarySnapshotHeader struct {
+	Steps     int64
+	TimeScale float64
+	Zoom      float64
+	OffsetX   float64
+	OffsetY   float64
+
+	Theta            float64
+	G                float64
+	Softening        float64
+	TimestepMode     uint8
+	TimestepAccuracy float64
+	MaxTimestepLevel int32
+	PhysicsFlags     uint8
+	BoundaryMode     uint8
+
+	NextID   uint64
+	Entities uint32
+}
+
+type binarySnapshotEntity struct {
+	ID     uint64
+	PX, PY float64
+	VX, VY float64
+	AX, AY float64
+	R, M   float64
+	Flags  uint8
+}
+
+const binarySnapshotEntityDisabled = 1 << 0
+
+// binarySnapshotChunk is the number of entities read from a binary snapshot at a time.
+const binarySnapshotChunk = 4096
+
+// binarySnapshotEntityKindShift is the position of the body kind in the flags of a binary snapshot entity.
+const (
+	binarySnapshotEntityKindShift = 1
+	binarySnapshotEntityKindMask  = 0x3
+)
+
+// binarySnapshotContinuousCollisions is set in the physics flags of a binary snapshot if continuous collision detection is enabled.
+const binarySnapshotContinuousCollisions = 1 << 0
+
+// binarySnapshotMetadata is the metadata of the entity at an index in a binary snapshot.
+// Metadata is stored as JSON after the entities because it is optional and of variable size.
+type binarySnapshotMetadata struct {
+	Index    int       `json:"index"`
+	Metadata *Metadata `json:"metadata"`
+}
+
+// binaryWriter writes little endian values until the first error.
+type binaryWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (bw *binaryWriter) write(v interface{}) {
+	if bw.err != nil {
+		return
+	}
+	bw.err = binary.Write(bw.w, binary.LittleEndian, v)
+}
+
+func (bw *binaryWriter) writeString(v string) {
+	bw.write(uint16(len(v)))
+	bw.write([]byte(v))
+}
+
+func saveBinary(w io.Writer, snapshot *Snapshot) error {
+	bw := &binaryWriter{w: bufio.NewWriter(w)}
+
+	var flags uint8
+	if snapshot.Physics.ContinuousCollisions {
+		flags |= binarySnapshotContinuousCollisions
+	}
+
+	bw.write(snapshotMagic)
+	bw.write(uint16(snapshot.Version))
+	bw.write(binarySnapshotHeader{
+		Steps:            int64(snapshot.Steps),
+		TimeScale:        snapshot.TimeScale,
+		Zoom:             snapshot.Camera.Zoom,
+		OffsetX:          snapshot.Camera.Offset.X,
+		OffsetY:          snapshot.Camera.Offset.Y,
+		Theta:            snapshot.Physics.Theta,
+		G:                snapshot.Physics.G,
+		Softening:        snapshot.Physics.Softening,
+		TimestepMode:     uint8(snapshot.Physics.TimestepMode),
+		TimestepAccuracy: snapshot.Physics.TimestepAccuracy,
+		MaxTimestepLevel: int32(snapshot.Physics.MaxTimestepLevel),
+		PhysicsFlags:     flags,
+		BoundaryMode:     uint8(snapshot.Physics.BoundaryMode),
+		NextID:           uint64(snapshot.NextID),
+		Entities:         uint32(len(snapshot.Entities)),
+	})
+	bw.writeString(snapshot.Physics.ForceLaw)
+	bw.writeString(snapshot.Physics.Integrator)
+	bw.writeString(snapshot.Physics.CollisionResolver)
+	bw.writeString(string(snapshot.Physics.CollisionResolverParameters))
+
+	entities := make([]binarySnapshotEntity, len(snapshot.Entities))
+	var metadata []binarySnapshotMetadata
+	for i, se := range snapshot.Entities {
+		entities[i] = binarySnapshotEntity{
+			ID: uint64(se.ID),
+			PX: se.P.X, PY: se.P.Y,
+			VX: se.V.X, VY: se.V.Y,
+			AX: se.A.X, AY: se.A.Y,
+			R: se.R, M: se.M,
+		}
+		if se.Disabled {
+			entities[i].Flags |= binarySnapshotEntityDisabled
+		}
+		entities[i].Flags |= (uint8(se.Kind) & binarySnapshotEntityKindMask) << binarySnapshotEntityKindShift
+
+		if se.Metadata != nil {
+			metadata = append(metadata, binarySnapshotMetadata{Index: i, Metadata: se.Metadata})
+		}
+	}
+	bw.write(entities)
+
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	bw.write(uint32(len(b)))
+	bw.write(b)
+
+	if bw.err != nil {
+		return bw.err
+	}
+
+	return bw.w.(*bufio.Writer).Flush()
+}
+
+// binaryReader reads little endian values until the first error.
+type binaryReader struct {
+	r   io.Reader
+	err error
+}
+
+func (br *binaryReader) read(v interface{}) {
+	if br.err != nil {
+		return
+	}
+	br.err = binary.Read(br.r, binary.LittleEndian, v)
+}
+
+func (br *binaryReader) readString() string {
+	var n uint16
+	br.read(&n)
+	if br.err != nil {
+		return ""
+	}
+
+	b := make([]byte, n)
+	br.read(b)
+	return string(b)
+}
+
+func loadBinary(r io.Reader) (*Snapshot, error) {
+	br := &binaryReader{r: r}
+
+	var magic [4]byte
+	var version uint16
+	var header binarySnapshotHeader
+
+	br.read(&magic)
+	br.read(&version)
+	if br.err == nil && version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", version)
+	}
+
+	br.read(&header)
+
+	snapshot := &Snapshot{
+		Version:   int(version),
+		Steps:     int(header.Steps),
+		TimeScale: header.TimeScale,
+		Camera: draw.Camera{
+			Zoom: header.Zoom,
+			Offset: internal.Vector{
+				X: header.OffsetX,
+				Y: header.OffsetY,
+			},
+		},
+		Physics: SnapshotPhysics{
+			Theta:            header.Theta,
+			G:                header.G,
+			Softening:        header.Softening,
+			TimestepMode:     TimestepMode(header.TimestepMode),
+			TimestepAccuracy: header.TimestepAccuracy,
+			MaxTimestepLevel: int(header.MaxTimestepLevel),
+
+			ContinuousCollisions: header.PhysicsFlags&binarySnapshotContinuousCollisions != 0,
+
+			BoundaryMode: BoundaryMode(header.BoundaryMode),
+		},
+		NextID: EntityID(header.NextID),
+	}
+
+	snapshot.Physics.ForceLaw = br.readString()
+	snapshot.Physics.Integrator = br.readString()
+	snapshot.Physics.CollisionResolver = br.readString()
+	if parameters := br.readString(); parameters != "" {
+		snapshot.Physics.CollisionResolverParameters = json.RawMessage(parameters)
+	}
+
+	if br.err != nil {
+		return nil, br.err
+	}
+
+	// Entities are read in chunks so that a corrupt count cannot allocate more memory than the snapshot contains
+	var entities []binarySnapshotEntity
+	chunk := make([]binarySnapshotEntity, binarySnapshotChunk)
+	for remaining := int(header.Entities); remaining > 0; {
+		n := remaining
+		if n > len(chunk) {
+			n = len(chunk)
+		}
+
+		br.read(chunk[:n])
+		if br.err != nil {
+			return nil, br.err
+		}
+
+		entities = append(entities, chunk[:n]...)
+		remaining -= n
+	}
+
+	snapshot.Entities = make([]SnapshotEntity, len(entities))
+	for i, be := range entities {
+		snapshot.Entities[i] = SnapshotEntity{
+			ID:       EntityID(be.ID),
+			P:        internal.Vector{X: be.PX, Y: be.PY},
+			V:        internal.Vector{X: be.VX, Y: be.VY},
+			A:        internal.Vector{X: be.AX, Y: be.AY},
+			R:        be.R,
+			M:        be.M,
+			Disabled: be.Flags&binarySnapshotEntityDisabled != 0,
+			Kind:     BodyKind(be.Flags >> binarySnapshotEntityKindShift & binarySnapshotEntityKindMask),
+		}
+	}
+
+	var n uint32
+	br.read(&n)
+	if br.err != nil {
+		return nil, br.err
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(br.r, int64(n)))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != int(n) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	var metadata []binarySnapshotMetadata
+	if err := json.Unmarshal(b, &metadata); err != nil {
+		return nil, fmt.Errorf("invalid entity metadata: %s", err)
+	}
+
+	for _, m := range metadata {
+		if m.Index < 0 || m.Index >= len(snapshot.Entities) {
+			return nil, fmt.Errorf("metadata for entity %d out of range", m.Index)
+		}
+		snapshot.Entities[m.Index].Metadata = m.Metadata
+	}
+
+	return snapshot, nil
+}
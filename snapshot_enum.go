@@ -0,0 +1,59 @@
+// Code generated by go-enum
+// DO NOT EDIT!
+
+package universe
+
+import (
+	"fmt"
+)
+
+const (
+	// SnapshotFormatJson is a SnapshotFormat of type Json
+	SnapshotFormatJson SnapshotFormat = iota
+	// SnapshotFormatBinary is a SnapshotFormat of type Binary
+	SnapshotFormatBinary
+)
+
+const _SnapshotFormatName = "jsonbinary"
+
+var _SnapshotFormatMap = map[SnapshotFormat]string{
+	0: _SnapshotFormatName[0:4],
+	1: _SnapshotFormatName[4:10],
+}
+
+// String implements the Stringer interface.
+func (x SnapshotFormat) String() string {
+	if str, ok := _SnapshotFormatMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("SnapshotFormat(%d)", x)
+}
+
+var _SnapshotFormatValue = map[string]SnapshotFormat{
+	_SnapshotFormatName[0:4]:  0,
+	_SnapshotFormatName[4:10]: 1,
+}
+
+// ParseSnapshotFormat attempts to convert a string to a SnapshotFormat
+func ParseSnapshotFormat(name string) (SnapshotFormat, error) {
+	if x, ok := _SnapshotFormatValue[name]; ok {
+		return x, nil
+	}
+	return SnapshotFormat(0), fmt.Errorf("%s is not a valid SnapshotFormat", name)
+}
+
+// MarshalText implements the text marshaller method
+func (x SnapshotFormat) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method
+func (x *SnapshotFormat) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseSnapshotFormat(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
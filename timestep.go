@@ -0,0 +1,156 @@
+package universe
+
+import (
+	"math"
+	"time"
+)
+
+//go:generate go run github.com/abice/go-enum -f=$GOFILE --noprefix --marshal
+
+// TimestepMode selects how each physics step of PhysicsConstantTimestep is subdivided.
+//
+// FixedTimestep advances every entity by exactly one step.
+//
+// AdaptiveTimestep divides each step into global sub-steps sized by the fastest moving or most strongly accelerated entity.
+//
+// BlockTimestep advances each entity at its own power-of-two division of the step.
+// Block timesteps always use a kick-drift-kick leapfrog scheme regardless of the simulation's integrator.
+/*
+ENUM(
+FixedTimestep,
+AdaptiveTimestep,
+BlockTimestep
+)
+*/
+type TimestepMode uint8
+
+const (
+	// DefaultTimestepAccuracy is the default fraction of an entity's radius it may move within a sub-step.
+	DefaultTimestepAccuracy = 0.5
+	// DefaultMaxTimestepLevel is the default number of times a step may be halved.
+	DefaultMaxTimestepLevel = 6
+)
+
+// timestepCriterion calculates the largest timestep an entity should be advanced by.
+// An entity should move less than a fraction of its radius in a single timestep,
+// either by its current velocity or from rest by its current acceleration.
+func (s *Simulation) timestepCriterion(e *Entity) float64 {
+	criterion := math.Inf(1)
+	if a := math.Sqrt(e.A.Dot()); a > 0 {
+		criterion = math.Sqrt(e.R / a)
+	}
+	if v := math.Sqrt(e.V.Dot()); v > 0 {
+		criterion = math.Min(criterion, e.R/v)
+	}
+
+	return s.timestepAccuracy * criterion
+}
+
+// timestepLevel calculates the number of times the timestep needs to be halved to satisfy the criterion.
+func (s *Simulation) timestepLevel(timestep, criterion float64) int {
+	if criterion >= timestep {
+		return 0
+	}
+
+	level := int(math.Ceil(math.Log2(timestep / criterion)))
+	if level > s.maxTimestepLevel {
+		return s.maxTimestepLevel
+	}
+
+	return level
+}
+
+func (s *Simulation) runAdaptiveTimeStep(timestep float64) {
+	minimum := timestep / float64(int(1)<<uint(s.maxTimestepLevel))
+
+	for remaining := timestep; remaining > 0; {
+		h := timestep
+		for _, e := range s.entities {
+			h = math.Min(h, s.timestepCriterion(e))
+		}
+
+		h = math.Max(h, minimum)
+
+		// Avoid leaving a remainder smaller than the minimum timestep
+		if remaining-h < minimum/2 {
+			h = remaining
+		}
+
+		s.integrate(h)
+		remaining -= h
+	}
+}
+
+func (s *Simulation) runBlockTimeStep(timestep float64) {
+	s.created = s.created[:0]
+	s.accelerate()
+
+	var maxLevel int
+	for _, e := range s.entities {
+		e.level = s.timestepLevel(timestep, s.timestepCriterion(e))
+		if e.level > maxLevel {
+			maxLevel = e.level
+		}
+	}
+
+	substeps := 1 << uint(maxLevel)
+	h := timestep / float64(substeps)
+
+	for k := 0; k < substeps; k++ {
+		// Open the interval of each entity whose own timestep begins at this sub-step
+		for _, e := range s.entities {
+			if k%(substeps>>uint(e.level)) == 0 {
+				e.Kick(timestep / float64(int(1)<<uint(e.level)) / 2)
+			}
+		}
+
+		s.beginSweep()
+		for _, e := range s.entities {
+			e.Step(h)
+		}
+		s.sweep(h)
+		s.levelCreated(maxLevel, h, true)
+		s.applyBoundary()
+
+		// Close the interval of each entity whose own timestep ends at this sub-step
+		s.active = s.active[:0]
+		for _, e := range s.entities {
+			if (k+1)%(substeps>>uint(e.level)) == 0 {
+				s.active = append(s.active, e)
+			}
+		}
+
+		compiled := s.compile()
+
+		perf := time.Now()
+		s.Accelerate(s.active)
+		s.stats.Interactions += time.Now().Sub(perf)
+
+		for _, e := range s.active {
+			e.Kick(timestep / float64(int(1)<<uint(e.level)) / 2)
+		}
+
+		s.stats.Substeps++
+		s.collide(compiled)
+		s.levelCreated(maxLevel, h, false)
+	}
+}
+
+// levelCreated advances the entities created by collisions within a block at the given level for the rest of the block.
+// Entities created part way through a sub-step are about to be closed by a half kick,
+// so their interval is opened immediately instead of at the start of the next sub-step.
+func (s *Simulation) levelCreated(level int, h float64, open bool) {
+	if len(s.created) == 0 {
+		return
+	}
+
+	s.Accelerate(s.created)
+	for _, e := range s.created {
+		e.level = level
+		if open {
+			e.Kick(h / 2)
+		}
+	}
+
+	s.created = s.created[:0]
+}
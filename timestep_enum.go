@@ -0,0 +1,63 @@
+// Code generated by go-enum
+// DO NOT EDIT!
+
+package universe
+
+import (
+	"fmt"
+)
+
+const (
+	// FixedTimestep is a TimestepMode of type FixedTimestep
+	FixedTimestep TimestepMode = iota
+	// AdaptiveTimestep is a TimestepMode of type AdaptiveTimestep
+	AdaptiveTimestep
+	// BlockTimestep is a TimestepMode of type BlockTimestep
+	BlockTimestep
+)
+
+const _TimestepModeName = "FixedTimestepAdaptiveTimestepBlockTimestep"
+
+var _TimestepModeMap = map[TimestepMode]string{
+	0: _TimestepModeName[0:13],
+	1: _TimestepModeName[13:29],
+	2: _TimestepModeName[29:42],
+}
+
+// String implements the Stringer interface.
+func (x TimestepMode) String() string {
+	if str, ok := _TimestepModeMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("TimestepMode(%d)", x)
+}
+
+var _TimestepModeValue = map[string]TimestepMode{
+	_TimestepModeName[0:13]:  0,
+	_TimestepModeName[13:29]: 1,
+	_TimestepModeName[29:42]: 2,
+}
+
+// ParseTimestepMode attempts to convert a string to a TimestepMode
+func ParseTimestepMode(name string) (TimestepMode, error) {
+	if x, ok := _TimestepModeValue[name]; ok {
+		return x, nil
+	}
+	return TimestepMode(0), fmt.Errorf("%s is not a valid TimestepMode", name)
+}
+
+// MarshalText implements the text marshaller method
+func (x TimestepMode) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method
+func (x *TimestepMode) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseTimestepMode(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
@@ -1,11 +1,81 @@
 package universe
 
 import (
-	"github.com/relvacode/universe/draw"
 	"github.com/relvacode/universe/internal"
+	"github.com/relvacode/universe/physics"
 	"math"
 )
 
+// treePadding is the fraction by which the root of the tree is enlarged beyond the entities it encloses,
+// because the right and bottom edges of a tree do not contain points lying on them.
+const treePadding = 1e-3
+
+// treeBoundary returns the smallest square that encloses the position of every live entity, with padding,
+// and the maximum depth of a tree with that root.
+// Disabled entities and entities without a finite position are ignored.
+func treeBoundary(entities EntityList) (internal.BoundingBox, int) {
+	var min, max internal.Vector
+	var found bool
+	var radius = math.Inf(1)
+	for _, e := range entities {
+		if e.Disabled || math.IsNaN(e.P.X+e.P.Y) || math.IsInf(e.P.X+e.P.Y, 0) {
+			continue
+		}
+
+		if e.R > 0 {
+			radius = math.Min(radius, e.R)
+		}
+
+		if !found {
+			min, max = e.P, e.P
+			found = true
+			continue
+		}
+
+		min.X = math.Min(min.X, e.P.X)
+		min.Y = math.Min(min.Y, e.P.Y)
+		max.X = math.Max(max.X, e.P.X)
+		max.Y = math.Max(max.Y, e.P.Y)
+	}
+
+	box := paddedSquare(min, max)
+	return box, treeDepth(box.W, radius)
+}
+
+// paddedSquare returns the square centred between min and max that encloses both, with padding.
+func paddedSquare(min, max internal.Vector) internal.BoundingBox {
+	size := math.Max(math.Max(max.X-min.X, max.Y-min.Y), 1) * (1 + treePadding)
+	center := internal.Vector{
+		X: (min.X + max.X) / 2,
+		Y: (min.Y + max.Y) / 2,
+	}
+
+	return internal.BoundingBox{
+		X: center.X - size/2,
+		Y: center.Y - size/2,
+		W: size,
+		H: size,
+	}
+}
+
+// treeDepth returns the maximum depth of a tree whose root has the given size,
+// so that its smallest nodes are about as wide as the smallest entity in the tree.
+// A root stretched by far away entities is then still subdivided down to the scale of the entities clustered within it.
+func treeDepth(size, radius float64) int {
+	if radius <= 0 || math.IsInf(radius, 0) {
+		return treeMinDepth
+	}
+
+	depth := math.Ceil(math.Log2(size / (2 * radius)))
+	if depth < treeMinDepth {
+		return treeMinDepth
+	}
+	if depth > treeMaxDepth {
+		return treeMaxDepth
+	}
+	return int(depth)
+}
+
 func NewQuadTree(boundary internal.BoundingBox, maxEntries, maxDepth int) *QuadTree {
 	return &QuadTree{
 		boundary:       boundary,
@@ -152,6 +222,100 @@ func (qt *QuadTree) Intersections(box internal.BoundingBox, f func(m *Entity) bo
 	return qt.nw.Intersections(box, f) && qt.ne.Intersections(box, f) && qt.sw.Intersections(box, f) && qt.se.Intersections(box, f)
 }
 
+// Acceleration calculates the gravitational acceleration of e caused by every other entity in the tree
+// using the Barnes-Hut approximation.
+// A node is treated as a single body at its center of mass when its size divided by its distance from e is less than theta.
+// A theta of 0 always opens every node, resulting in an exact summation.
+func (qt *QuadTree) Acceleration(e *Entity, theta float64, g physics.Gravity) internal.Vector {
+	return qt.AccelerationAt(e.P, e, theta, g)
+}
+
+// AccelerationAt calculates the gravitational acceleration at p caused by every entity in the tree other than e.
+func (qt *QuadTree) AccelerationAt(p internal.Vector, e *Entity, theta float64, g physics.Gravity) internal.Vector {
+	var a internal.Vector
+	qt.accelerate(p, e, theta, g, &a)
+	return a
+}
+
+func (qt *QuadTree) accelerate(p internal.Vector, e *Entity, theta float64, g physics.Gravity, a *internal.Vector) {
+	if qt.totalMass == 0 {
+		return
+	}
+
+	if qt.nw == nil {
+		for i := 0; i < len(qt.objects); i++ {
+			o := qt.objects[i]
+			if o == e || o.Disabled {
+				continue
+			}
+
+			f := g.Acceleration(p, o.P, o.M)
+			a.X += f.X
+			a.Y += f.Y
+		}
+		return
+	}
+
+	if !qt.opens(p, theta) {
+		f := g.Acceleration(p, qt.CenterOfMass(), qt.totalMass)
+		a.X += f.X
+		a.Y += f.Y
+		return
+	}
+
+	qt.nw.accelerate(p, e, theta, g, a)
+	qt.ne.accelerate(p, e, theta, g, a)
+	qt.sw.accelerate(p, e, theta, g, a)
+	qt.se.accelerate(p, e, theta, g, a)
+}
+
+// Potential calculates the gravitational potential at e caused by every other entity in the tree
+// using the same Barnes-Hut approximation as Acceleration.
+func (qt *QuadTree) Potential(e *Entity, theta float64, g physics.Gravity) float64 {
+	if qt.totalMass == 0 {
+		return 0
+	}
+
+	var u float64
+	if qt.nw == nil {
+		for i := 0; i < len(qt.objects); i++ {
+			o := qt.objects[i]
+			if o == e || o.Disabled {
+				continue
+			}
+
+			u += g.Potential(e.P, o.P, o.M)
+		}
+		return u
+	}
+
+	if !qt.opens(e.P, theta) {
+		return g.Potential(e.P, qt.CenterOfMass(), qt.totalMass)
+	}
+
+	u += qt.nw.Potential(e, theta, g)
+	u += qt.ne.Potential(e, theta, g)
+	u += qt.sw.Potential(e, theta, g)
+	u += qt.se.Potential(e, theta, g)
+	return u
+}
+
+// opens checks if the node is too close to p to be treated as a single body.
+func (qt *QuadTree) opens(p internal.Vector, theta float64) bool {
+	// A node that contains the point must always be opened, otherwise an entity would attract itself
+	if qt.boundary.ContainsPoint(p) {
+		return true
+	}
+
+	c := qt.CenterOfMass()
+	delta := internal.Vector{
+		X: c.X - p.X,
+		Y: c.Y - p.Y,
+	}
+
+	return math.Max(qt.boundary.W, qt.boundary.H) >= theta*math.Sqrt(delta.Dot())
+}
+
 func (qt *QuadTree) AppendLeaves(arr []*QuadTree) []*QuadTree {
 	if qt.nw == nil {
 		if len(qt.objects) > 0 {
@@ -167,28 +331,3 @@ func (qt *QuadTree) AppendLeaves(arr []*QuadTree) []*QuadTree {
 
 	return arr
 }
-
-func (qt *QuadTree) Draw(ctx *draw.Context) {
-	if len(qt.objects) > 0 {
-		ctx.BeginPath()
-		ctx.MoveTo(qt.boundary.X, qt.boundary.Y)
-		ctx.LineTo(qt.boundary.X+qt.boundary.W, qt.boundary.Y)
-		ctx.LineTo(qt.boundary.X+qt.boundary.W, qt.boundary.Y+qt.boundary.H)
-		ctx.LineTo(qt.boundary.X, qt.boundary.Y+qt.boundary.H)
-		ctx.LineTo(qt.boundary.X, qt.boundary.Y)
-		ctx.Stroke()
-
-		ctx.BeginPath()
-
-		c := qt.CenterOfMass()
-		ctx.Arc(c.X, c.Y, math.Cbrt(qt.totalMass), 0, math.Pi*2)
-		ctx.Stroke()
-	}
-
-	if qt.nw != nil {
-		qt.nw.Draw(ctx)
-		qt.ne.Draw(ctx)
-		qt.sw.Draw(ctx)
-		qt.se.Draw(ctx)
-	}
-}
@@ -0,0 +1,50 @@
+package universe
+
+import (
+	"github.com/relvacode/universe/internal"
+	"math/rand"
+	"testing"
+)
+
+// maxLeafEntries returns the largest number of entities in a leaf of the tree.
+func maxLeafEntries(qt *QuadTree) int {
+	if qt.nw == nil {
+		return len(qt.objects)
+	}
+
+	n := 0
+	for _, child := range []*QuadTree{qt.nw, qt.ne, qt.sw, qt.se} {
+		if c := maxLeafEntries(child); c > n {
+			n = c
+		}
+	}
+	return n
+}
+
+// TestCompileTreeOutlier checks that a far away entity does not stop the tree subdividing a cluster of entities.
+func TestCompileTreeOutlier(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	entities := EntityList{
+		NewEntity(internal.Vector{X: 1e7, Y: 1e7}, internal.Vector{}, 1),
+	}
+	for i := 0; i < 1000; i++ {
+		entities = append(entities, NewEntity(
+			internal.Vector{X: 100 * rng.Float64(), Y: 100 * rng.Float64()},
+			internal.Vector{},
+			1,
+		))
+	}
+
+	boundary, depth := treeBoundary(entities)
+	if depth <= treeMinDepth || depth > treeMaxDepth {
+		t.Fatalf("expected a depth between %d and %d for a root of size %g, got %d", treeMinDepth, treeMaxDepth, boundary.W, depth)
+	}
+
+	s := NewSimulation(internal.BoundingBox{W: 100, H: 100})
+	s.CompileTree(entities)
+
+	if n := maxLeafEntries(s.tree); n > treeMaxEntries {
+		t.Fatalf("expected at most %d entities in a leaf, got %d", treeMaxEntries, n)
+	}
+}
@@ -7,10 +7,9 @@ import (
 
 type View interface {
 	TimeScale() float64
-	CollisionResolver() CollisionResolver
+	Simulation() *Simulation
 	Camera() *draw.Camera
-	Entities() *EntityList
 
 	Update(box internal.BoundingBox)
-	Draw(ctx *draw.Context)
-}
\ No newline at end of file
+	Draw(ctx draw.Canvas)
+}